@@ -0,0 +1,88 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command golight is the command line companion of the golight services.
+//
+// Usage:
+//
+//	golight routes [-json] URL
+//
+// The routes command lists the routes of a running service. URL is the
+// address of the ghttp.RoutesHandler of the service, e.g.
+//
+//	golight routes http://localhost:8080/debug/routes
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+const usage = `Usage:
+	golight routes [-json] URL
+
+Commands:
+	routes	lists the routes served by the ghttp.RoutesHandler at URL
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "routes":
+		err = routes(os.Stdout, args)
+	default:
+		fmt.Fprintf(os.Stderr, "golight: unknown command %q\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "golight:", err)
+		os.Exit(1)
+	}
+}
+
+// routes fetches the routes from the RoutesHandler and prints them.
+func routes(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("routes", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the routes as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("routes: URL is required")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	res, err := client.Get(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("routes: %s", res.Status)
+	}
+
+	var routes []ghttp.RouteInfo
+	if err := json.NewDecoder(res.Body).Decode(&ghttp.Response{Data: &routes}); err != nil {
+		return fmt.Errorf("routes: %v", err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	}
+	return ghttp.WriteRoutesTable(w, routes)
+}
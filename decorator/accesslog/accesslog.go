@@ -0,0 +1,196 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package accesslog
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/ghttp"
+	"github.com/dlmc/golight/logger"
+)
+
+// Format is the format of the access log lines.
+type Format int
+
+const (
+	Structured Format = iota // Structured logs one line through the logger of the request
+	Combined                 // Combined writes the Apache Combined Log Format lines
+)
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Format Format
+	// Writer receives the Combined lines, or the Structured lines of the
+	// requests not decorated by logging.CreateDecor. os.Stdout if nil.
+	Writer io.Writer
+}
+
+// CreateDecor creates a decorator that logs the Structured access line
+// of each request, refer to CreateDecorWith for details.
+func CreateDecor() decorator.Decorator {
+	return CreateDecorWith(Options{})
+}
+
+// CreateDecorWith creates a decorator that logs one access line per request
+// once it has been served.
+// The Structured line carries the method, path, route pattern, status,
+// bytes written, duration, remote address and user agent. It is logged
+// through the logger of logging.CreateDecor, so it also carries the fields
+// added by the inner decorators, e.g. the request id. The level is info
+// for 1xx-3xx, warn for 4xx and error for 5xx.
+// A panic of the handler is logged as a 500 and raised again, so the
+// decorator may be inner or outer to recovery.CreateDecor.
+// Usage example:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), requestid.CreateDecor(),
+		accesslog.CreateDecor(), recovery.CreateDecor(), logging.CreateDecor(lc))
+
+	//{"l":"info","method":"GET","path":"/users/7","route":"/users/{id}","status":200,
+	// "bytes":42,"dur":3,"remote":"10.0.0.1:5122","ua":"curl/8.0","request_id":"...","m":"request"}
+*/
+// The Combined format writes to opts.Writer, e.g.
+//	10.0.0.1 - - [02/Jan/2006:15:04:05 -0700] "GET /users/7 HTTP/1.1" 200 42 "-" "curl/8.0"
+func CreateDecorWith(opts Options) decorator.Decorator {
+	out := opts.Writer
+	if out == nil {
+		out = os.Stdout
+	}
+	fallback := logging.NewContext(out)
+	var mu sync.Mutex
+
+	return decorator.Named("accesslog", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			start := time.Now()
+			w := ghttp.WrapWriter(h.W)
+			orig := h.W
+			h.W = w
+			rc := c
+			defer func() {
+				h.W = orig
+				status := w.Status()
+				v := recover()
+				switch {
+				case v != nil:
+					status = http.StatusInternalServerError
+				case status == 0:
+					//net/http sends 200 for the handlers that write nothing
+					status = http.StatusOK
+				}
+				if opts.Format == Combined {
+					line := appendCombined(nil, h, start, status, w.Size())
+					mu.Lock()
+					out.Write(line)
+					mu.Unlock()
+				} else {
+					logLine(rc, h, fallback, start, status, w.Size())
+				}
+				if v != nil {
+					panic(v)
+				}
+			}()
+			rc = next.ServeHTTPWithCtx(c, h)
+			return rc
+		})
+	})
+}
+
+// logLine logs the Structured line through the logger of the Ctx, or the
+// fallback.
+func logLine(c ghttp.Ctx, h *ghttp.Http, fallback logger.Context, start time.Time, status int, size int64) {
+	lc, ok := logging.GetLogger(c)
+	if !ok {
+		lc = fallback
+	}
+	l := lc.Logger()
+	e := l.Info()
+	switch {
+	case status >= 500:
+		e = l.Error()
+	case status >= 400:
+		e = l.Warn()
+	}
+	e.Str("method", h.R.Method).
+		Str("path", h.R.URL.Path).
+		Str("route", h.Pattern).
+		Int("status", status).
+		Int64("bytes", size).
+		Dur("dur", time.Since(start)).
+		Str("remote", h.R.RemoteAddr).
+		Str("ua", h.R.UserAgent()).
+		Msg("request")
+}
+
+// appendCombined appends the Apache Combined Log Format line:
+//	host ident authuser [date] "request" status bytes "referer" "user-agent"
+func appendCombined(b []byte, h *ghttp.Http, start time.Time, status int, size int64) []byte {
+	r := h.R
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+
+	b = append(b, host...)
+	b = append(b, " - "...)
+	b = appendQuotable(b, user)
+	b = append(b, " ["...)
+	b = start.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, "] \""...)
+	b = appendQuotable(b, r.Method)
+	b = append(b, ' ')
+	b = appendQuotable(b, r.URL.RequestURI())
+	b = append(b, ' ')
+	b = appendQuotable(b, r.Proto)
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(status), 10)
+	b = append(b, ' ')
+	if size == 0 {
+		b = append(b, '-')
+	} else {
+		b = strconv.AppendInt(b, size, 10)
+	}
+	b = append(b, " \""...)
+	b = appendQuotable(b, dash(r.Referer()))
+	b = append(b, "\" \""...)
+	b = appendQuotable(b, dash(r.UserAgent()))
+	return append(b, "\"\n"...)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendQuotable appends s escaping the quotes, backslashes and control
+// characters the way Apache does, so a client can not forge log lines.
+func appendQuotable(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
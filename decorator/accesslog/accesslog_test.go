@@ -0,0 +1,119 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package accesslog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/accesslog"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/decorator/recovery"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Http request handler that responds with the status in the path
+var thStatus = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	switch h.Params.Get("status") {
+	case "404":
+		h.W.WriteHeader(http.StatusNotFound)
+	case "500":
+		h.W.WriteHeader(http.StatusInternalServerError)
+	case "empty":
+		return c
+	}
+	h.W.Write([]byte("hello"))
+	return c
+})
+
+//Test cases for the structured access log
+func TestAccessLog(t *testing.T) {
+	testCases := []struct {
+		status string
+		code   float64
+		bytes  float64
+		level  string
+	}{
+		{"200", 200, 5, "info"},
+		{"empty", 200, 0, "info"},
+		{"404", 404, 5, "warn"},
+		{"500", 500, 5, "error"},
+	}
+
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		mux := ghttp.NewMux()
+		mux.Handle("/status/{status}", ghttp.Router{"GET": decorator.Decorate(thStatus,
+			accesslog.CreateDecor(), logging.CreateDecor(logging.NewContext(out)))})
+		r := httptest.NewRequest("GET", "/status/"+tc.status, nil)
+		r.Header.Set("User-Agent", "test/1.0")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+			t.Fatalf("%d. AccessLog failed: %v %s", i, err, out.String())
+		}
+		if entry["l"] != tc.level || entry["m"] != "request" || entry["method"] != "GET" ||
+			entry["path"] != "/status/"+tc.status || entry["route"] != "/status/{status}" ||
+			entry["status"] != tc.code || entry["bytes"] != tc.bytes || entry["ua"] != "test/1.0" ||
+			entry["remote"] != "192.0.2.1:1234" {
+			t.Errorf("%d. AccessLog failed, got: %s", i, out.String())
+		}
+		if _, ok := entry["dur"].(float64); !ok {
+			t.Errorf("%d. AccessLog duration failed, got: %v", i, entry["dur"])
+		}
+	}
+}
+
+//Test cases for the access log of a panicking handler, inner to recovery
+func TestAccessLogPanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	mux := ghttp.NewMux()
+	mux.Handle("/panic", ghttp.Router{"GET": decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		panic("boom")
+	}), accesslog.CreateDecor(), recovery.CreateDecor(), logging.CreateDecor(logging.NewContext(out)))})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	var entry map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var e map[string]interface{}
+		if err := json.Unmarshal(line, &e); err != nil {
+			t.Fatalf("AccessLog panic failed: %v %s", err, out.String())
+		}
+		if e["m"] == "request" {
+			entry = e
+		}
+	}
+	if w.Code != 500 || entry == nil || entry["l"] != "error" || entry["status"] != float64(500) || entry["route"] != "/panic" {
+		t.Errorf("AccessLog panic failed, got: %d %s", w.Code, out.String())
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	out := &bytes.Buffer{}
+	h := decorator.Decorate(thStatus, accesslog.CreateDecorWith(accesslog.Options{Format: accesslog.Combined, Writer: out}))
+	r := httptest.NewRequest("GET", "/a?b=1", nil)
+	r.SetBasicAuth("frank", "pw")
+	r.Header.Set("User-Agent", `evil"agent`)
+	r.Header.Set("Referer", "http://example.com/")
+	w := httptest.NewRecorder()
+	hp := &ghttp.Http{W: w, R: r}
+	h.ServeHTTPWithCtx(nil, hp)
+
+	want := regexp.MustCompile(`^192\.0\.2\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [-+]\d{4}\] ` +
+		`"GET /a\?b=1 HTTP/1\.1" 200 5 "http://example\.com/" "evil\\"agent"` + "\n$")
+	if !want.MatchString(out.String()) {
+		t.Errorf("AccessLog Combined failed, got: %q", out.String())
+	}
+	if hp.W != w {
+		t.Errorf("AccessLog did not restore the ResponseWriter")
+	}
+}
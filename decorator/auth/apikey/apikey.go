@@ -0,0 +1,102 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apikey
+
+import (
+	"strings"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// DefaultHeader is the header the API key is read from by default.
+const DefaultHeader = "X-API-Key"
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Store  auth.Store
+	Header string // the header of the key, DefaultHeader if both Header and Query are empty
+	Query  string // the query param of the key, e.g. api_key, not read if empty
+	// SplitID splits the keys of the form <id><SplitID><secret>, e.g. ".",
+	// to pass the id and the secret to the Store, e.g. an auth.Htpasswd.
+	// The whole key is passed as the secret if empty.
+	SplitID string
+	Realm   string // the realm of the WWW-Authenticate challenge
+}
+
+// CreateDecor creates a decorator that authenticates the requests with the
+// API key of their X-API-Key header, refer to CreateDecorWith for details.
+func CreateDecor(store auth.Store) decorator.Decorator {
+	return CreateDecorWith(Options{Store: store})
+}
+
+// CreateDecorWith creates a decorator that authenticates the requests with
+// an API key, read from the header then from the query param.
+// The key is verified by the store, and the auth.Principal it returns is
+// stored in the Ctx with the ApiKey scheme.
+// The requests without a valid key get a 401, through respond.CreateDecor
+// that must be outer.
+// Usage example:
+/*
+	partners := auth.APIKeys(map[string]*auth.Principal{
+		os.Getenv("PARTNER_A_KEY"): {Subject: "partner-a", Scopes: []string{"orders:read"}},
+	})
+	h := decorator.Decorate(hdl, apikey.CreateDecorWith(apikey.Options{
+		Store: partners, Header: "X-API-Key", Query: "api_key",
+	}), respond.CreateDecor())
+*/
+// Prefer the header, the query params end up in the access logs.
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.Store == nil {
+		panic("apikey: nil Options.Store")
+	}
+	if opts.Header == "" && opts.Query == "" {
+		opts.Header = DefaultHeader
+	}
+	challenge := auth.Challenge("ApiKey", "realm", opts.Realm)
+	return decorator.Named("apikey", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			key := ""
+			if opts.Header != "" {
+				key = h.R.Header.Get(opts.Header)
+			}
+			if key == "" && opts.Query != "" {
+				q := h.Query
+				if q == nil {
+					q = h.R.URL.Query()
+				}
+				key = q.Get(opts.Query)
+			}
+			if key == "" {
+				return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: "missing API key"})
+			}
+
+			id, secret := "", key
+			if opts.SplitID != "" {
+				var ok bool
+				if id, secret, ok = strings.Cut(key, opts.SplitID); !ok {
+					return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: "invalid API key"})
+				}
+			}
+			p, err := opts.Store.Authenticate(h.R.Context(), id, secret)
+			if err != nil {
+				h.Err = err
+				return c
+			}
+			if p == nil {
+				return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: "invalid API key"})
+			}
+			if p.Scheme == "" {
+				//copy, the Principal belongs to the store
+				pp := *p
+				pp.Scheme = "ApiKey"
+				p = &pp
+			}
+			c = auth.WithPrincipal(c, p)
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
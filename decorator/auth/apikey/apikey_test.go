@@ -0,0 +1,94 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apikey_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/decorator/auth/apikey"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//Http request handler that responds with the principal
+var thPrincipal = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	p, _ := auth.GetPrincipal(c)
+	h.Resp.Message = p.Scheme + " " + p.Subject
+	return c
+})
+
+func serve(h ghttp.Handler, target, header string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	if header != "" {
+		r.Header.Set("X-API-Key", header)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r, Query: r.URL.Query()})
+	return w
+}
+
+//Test cases for the API key decorator
+func TestAPIKey(t *testing.T) {
+	pa := &auth.Principal{Subject: "partner-a"}
+	store := auth.APIKeys(map[string]*auth.Principal{"key-a": pa})
+	h := decorator.Decorate(thPrincipal, apikey.CreateDecorWith(apikey.Options{
+		Store: store, Header: apikey.DefaultHeader, Query: "api_key", Realm: "partners"}), respond.CreateDecor())
+
+	testCases := []struct {
+		target, header string
+		code           int
+		body           string
+	}{
+		{"/", "key-a", 200, `{"code":200,"message":"ApiKey partner-a"}`},
+		{"/?api_key=key-a", "", 200, `{"code":200,"message":"ApiKey partner-a"}`},
+		{"/?api_key=key-a", "bad", 401, `{"code":401,"message":"auth: invalid API key"}`},
+		{"/", "", 401, `{"code":401,"message":"auth: missing API key"}`},
+	}
+	for i, tc := range testCases {
+		w := serve(h, tc.target, tc.header)
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.body {
+			t.Errorf("%d. APIKey failed, got: %d %s", i, w.Code, w.Body.String())
+		}
+		if tc.code == 401 && w.Header().Get("WWW-Authenticate") != `ApiKey realm="partners"` {
+			t.Errorf("%d. APIKey challenge failed, got: %q", i, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+	if pa.Scheme != "" {
+		t.Errorf("APIKey changed the Principal of the store, got scheme: %q", pa.Scheme)
+	}
+
+	//the query param is not read by default
+	h = decorator.Decorate(thPrincipal, apikey.CreateDecor(store), respond.CreateDecor())
+	if w := serve(h, "/?api_key=key-a", ""); w.Code != 401 {
+		t.Errorf("APIKey default failed, got: %d", w.Code)
+	}
+}
+
+func TestAPIKeySplit(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	ht, err := auth.ParseHtpasswd(strings.NewReader("partner-b:" + string(hash)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := decorator.Decorate(thPrincipal, apikey.CreateDecorWith(apikey.Options{Store: ht, SplitID: "."}), respond.CreateDecor())
+	testCases := []struct {
+		key  string
+		code int
+	}{
+		{"partner-b.s3cret", 200},
+		{"partner-b.wrong", 401},
+		{"s3cret", 401},
+	}
+	for i, tc := range testCases {
+		if w := serve(h, "/", tc.key); w.Code != tc.code {
+			t.Errorf("%d. APIKey split failed, got: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package auth holds what the authentication decorators of its sub
+// packages share: the Principal they store in the Ctx and the Error they
+// report through respond.CreateDecor.
+//
+// The authentication decorators report their errors in h.Err, so they
+// must be decorated by respond.CreateDecor:
+/*
+	h := decorator.Decorate(hdl, jwt.CreateDecor[Claims](cfg), respond.CreateDecor(), logging.CreateDecor(lc))
+*/
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Principal is the authenticated client, the same for all the schemes.
+type Principal struct {
+	Subject     string      // the user or client id
+	Scheme      string      // the scheme it was authenticated with, e.g. Bearer, Basic or ApiKey
+	Roles       []string    // the roles granted to the principal
+	Scopes      []string    // the OAuth2 scopes granted to the principal
+	Permissions []string    // the fine grained permissions granted to the principal
+	Claims      interface{} // the scheme specific data, e.g. the JWT claims
+}
+
+// HasRole reports whether the principal has the role.
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal has the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// HasPermission reports whether the principal has the permission.
+func (p *Principal) HasPermission(perm string) bool {
+	return contains(p.Permissions, perm)
+}
+
+func contains(strs []string, s string) bool {
+	for _, e := range strs {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Internal typed key
+var principalKey = ghttp.NewKey[*Principal]("auth.principal")
+
+// WithPrincipal returns a child Ctx of c that carries the principal.
+func WithPrincipal(c ghttp.Ctx, p *Principal) ghttp.Ctx {
+	return principalKey.With(c, p)
+}
+
+// GetPrincipal returns the Principal in the request Context and whether it
+// was found.
+func GetPrincipal(c ghttp.Ctx) (*Principal, bool) {
+	p, ok := principalKey.From(c)
+	return p, ok && p != nil
+}
+
+// Subject returns the subject of the principal, "" if the request is not
+// authenticated. It can be used as a ratelimit.KeyFunc.
+func Subject(c ghttp.Ctx, h *ghttp.Http) string {
+	if p, ok := GetPrincipal(c); ok {
+		return p.Subject
+	}
+	return ""
+}
+
+// Error is an authentication or authorization error, reported with its
+// status code by respond.CreateDecor.
+type Error struct {
+	Status    int    // 401 or 403
+	Challenge string // the WWW-Authenticate header sent with a 401
+	Message   string
+}
+
+func (e *Error) Error() string {
+	return "auth: " + e.Message
+}
+
+// StatusCode returns the status code, 401 if not set.
+func (e *Error) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusUnauthorized
+	}
+	return e.Status
+}
+
+// Challenge returns the WWW-Authenticate challenge of the scheme with the
+// parameters given as name / value pairs, the empty values are skipped:
+//	auth.Challenge("Bearer", "realm", "api", "error", "invalid_token")
+//	Bearer realm="api", error="invalid_token"
+func Challenge(scheme string, params ...string) string {
+	var sb strings.Builder
+	sb.WriteString(scheme)
+	sep := " "
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i+1] == "" {
+			continue
+		}
+		sb.WriteString(sep)
+		sb.WriteString(params[i])
+		sb.WriteString(`="`)
+		sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(params[i+1]))
+		sb.WriteByte('"')
+		sep = ", "
+	}
+	return sb.String()
+}
+
+// Fail reports the error into h.Err and sets its WWW-Authenticate header.
+// It returns c, so the decorators can return auth.Fail(c, h, err).
+func Fail(c ghttp.Ctx, h *ghttp.Http, err *Error) ghttp.Ctx {
+	if err.Challenge != "" {
+		h.W.Header().Add("WWW-Authenticate", err.Challenge)
+	}
+	h.Err = err
+	return c
+}
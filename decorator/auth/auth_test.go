@@ -0,0 +1,52 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Test cases for the auth helpers
+func TestChallenge(t *testing.T) {
+	testCases := []struct {
+		got, want string
+	}{
+		{auth.Challenge("Bearer"), "Bearer"},
+		{auth.Challenge("Basic", "realm", "api", "charset", "UTF-8"), `Basic realm="api", charset="UTF-8"`},
+		{auth.Challenge("Bearer", "realm", "", "error", "invalid_token"), `Bearer error="invalid_token"`},
+		{auth.Challenge("Bearer", "error_description", `bad "q"`), `Bearer error_description="bad \"q\""`},
+	}
+	for i, tc := range testCases {
+		if tc.got != tc.want {
+			t.Errorf("%d. Challenge failed, got: %s, want: %s", i, tc.got, tc.want)
+		}
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	h := &ghttp.Http{W: httptest.NewRecorder()}
+	if _, ok := auth.GetPrincipal(nil); ok || auth.Subject(context.Background(), h) != "" {
+		t.Errorf("GetPrincipal found a principal")
+	}
+	p := &auth.Principal{Subject: "u1", Roles: []string{"admin"}, Scopes: []string{"read"}}
+	c := auth.WithPrincipal(context.Background(), p)
+	if got, ok := auth.GetPrincipal(c); !ok || got != p || auth.Subject(c, h) != "u1" {
+		t.Errorf("GetPrincipal failed, got: %v", got)
+	}
+	if !p.HasRole("admin") || p.HasRole("user") || !p.HasScope("read") || p.HasScope("write") {
+		t.Errorf("Principal roles and scopes failed")
+	}
+
+	err := &auth.Error{Challenge: `Bearer realm="api"`, Message: "missing"}
+	auth.Fail(c, h, err)
+	if h.Err != err || err.StatusCode() != 401 || h.W.Header().Get("WWW-Authenticate") != `Bearer realm="api"` {
+		t.Errorf("Fail failed, got: %v %v", h.Err, h.W.Header())
+	}
+}
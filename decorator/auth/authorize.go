@@ -0,0 +1,125 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"net/http"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Predicate reports whether the principal is authorized for the request.
+type Predicate func(c ghttp.Ctx, h *ghttp.Http, p *Principal) bool
+
+// AnyRole is satisfied by a principal that has any of the roles.
+func AnyRole(roles ...string) Predicate {
+	return anyOf(roles, (*Principal).HasRole)
+}
+
+// AllRoles is satisfied by a principal that has all the roles.
+func AllRoles(roles ...string) Predicate {
+	return allOf(roles, (*Principal).HasRole)
+}
+
+// AnyScope is satisfied by a principal that has any of the scopes.
+func AnyScope(scopes ...string) Predicate {
+	return anyOf(scopes, (*Principal).HasScope)
+}
+
+// AllScopes is satisfied by a principal that has all the scopes.
+func AllScopes(scopes ...string) Predicate {
+	return allOf(scopes, (*Principal).HasScope)
+}
+
+// AnyPermission is satisfied by a principal that has any of the permissions.
+func AnyPermission(perms ...string) Predicate {
+	return anyOf(perms, (*Principal).HasPermission)
+}
+
+// AllPermissions is satisfied by a principal that has all the permissions.
+func AllPermissions(perms ...string) Predicate {
+	return allOf(perms, (*Principal).HasPermission)
+}
+
+func anyOf(strs []string, has func(*Principal, string) bool) Predicate {
+	return func(c ghttp.Ctx, h *ghttp.Http, p *Principal) bool {
+		for _, s := range strs {
+			if has(p, s) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func allOf(strs []string, has func(*Principal, string) bool) Predicate {
+	return func(c ghttp.Ctx, h *ghttp.Http, p *Principal) bool {
+		for _, s := range strs {
+			if !has(p, s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any is satisfied when any of the predicates is.
+func Any(preds ...Predicate) Predicate {
+	return func(c ghttp.Ctx, h *ghttp.Http, p *Principal) bool {
+		for _, pred := range preds {
+			if pred(c, h, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// All is satisfied when all the predicates are.
+func All(preds ...Predicate) Predicate {
+	return func(c ghttp.Ctx, h *ghttp.Http, p *Principal) bool {
+		for _, pred := range preds {
+			if !pred(c, h, p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Require creates a decorator that lets through the requests whose
+// principal satisfies the predicate.
+// The requests without a principal get a 401, the unauthorized ones a 403,
+// through respond.CreateDecor that must be outer. The decorator must be
+// inner to the authentication decorator storing the principal.
+// Usage example:
+/*
+	authn := jwt.CreateDecor[Claims](cfg)
+	mux.Handle("/orders", ghttp.Router{
+		"GET":  decorator.Decorate(listOrders, auth.Require(auth.AnyScope("orders:read", "orders:write")), authn, respond.CreateDecor()),
+		"POST": decorator.Decorate(createOrder, auth.Require(auth.AllScopes("orders:write")), authn, respond.CreateDecor()),
+	})
+
+	//a custom predicate, the users can only read their own profile
+	self := func(c ghttp.Ctx, h *ghttp.Http, p *auth.Principal) bool {
+		return p.Subject == h.Params.Get("id")
+	}
+	auth.Require(auth.Any(auth.AnyRole("admin"), self))
+*/
+func Require(pred Predicate) decorator.Decorator {
+	return decorator.Named("authorize", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			p, ok := GetPrincipal(c)
+			if !ok {
+				return Fail(c, h, &Error{Status: http.StatusUnauthorized, Message: "not authenticated"})
+			}
+			if !pred(c, h, p) {
+				return Fail(c, h, &Error{Status: http.StatusForbidden, Message: "forbidden"})
+			}
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Decorator that authenticates the principals of the X-User header
+var principals = map[string]*auth.Principal{
+	"reader": {Subject: "reader", Scopes: []string{"orders:read"}},
+	"writer": {Subject: "writer", Scopes: []string{"orders:read", "orders:write"}, Permissions: []string{"orders.delete"}},
+	"admin":  {Subject: "admin", Roles: []string{"admin", "ops"}},
+}
+
+func tAuthn(next ghttp.Handler) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		if p, ok := principals[h.R.Header.Get("X-User")]; ok {
+			c = auth.WithPrincipal(c, p)
+		}
+		return next.ServeHTTPWithCtx(c, h)
+	})
+}
+
+var thOK = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.Resp.Message = "ok"
+	return c
+})
+
+//Test cases for the authorization decorators
+func TestRequire(t *testing.T) {
+	self := func(c ghttp.Ctx, h *ghttp.Http, p *auth.Principal) bool {
+		return p.Subject == h.Params.Get("user")
+	}
+	route := func(pred auth.Predicate) ghttp.Handler {
+		return decorator.Decorate(thOK, auth.Require(pred), tAuthn, respond.CreateDecor())
+	}
+	mux := ghttp.NewMux()
+	mux.Handle("/orders", ghttp.Router{
+		"GET":    route(auth.AnyScope("orders:read", "orders:write")),
+		"POST":   route(auth.AllScopes("orders:read", "orders:write")),
+		"DELETE": route(auth.Any(auth.AnyPermission("orders.delete"), auth.AllRoles("admin", "ops"))),
+	})
+	mux.Handle("/users/{user}", ghttp.Router{"GET": route(auth.Any(auth.AnyRole("admin"), self))})
+
+	const (
+		ok        = `{"code":200,"message":"ok"}`
+		forbidden = `{"code":403,"message":"auth: forbidden"}`
+		unauth    = `{"code":401,"message":"auth: not authenticated"}`
+	)
+	testCases := []struct {
+		method, path, user string
+		code               int
+		body               string
+	}{
+		{"GET", "/orders", "reader", 200, ok},
+		{"POST", "/orders", "reader", 403, forbidden},
+		{"POST", "/orders", "writer", 200, ok},
+		{"DELETE", "/orders", "writer", 200, ok},
+		{"DELETE", "/orders", "admin", 200, ok},
+		{"DELETE", "/orders", "reader", 403, forbidden},
+		{"GET", "/orders", "", 401, unauth},
+		{"GET", "/users/reader", "reader", 200, ok},
+		{"GET", "/users/writer", "reader", 403, forbidden},
+		{"GET", "/users/writer", "admin", 200, ok},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("X-User", tc.user)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.body {
+			t.Errorf("%d. Require failed, got: %d %s, want: %d %s", i, w.Code, w.Body.String(), tc.code, tc.body)
+		}
+	}
+}
+
+func TestAllOf(t *testing.T) {
+	p := &auth.Principal{Roles: []string{"a"}, Scopes: []string{"s"}}
+	if !auth.All(auth.AnyRole("a"), auth.AnyScope("s"))(nil, nil, p) ||
+		auth.All(auth.AnyRole("a"), auth.AnyScope("x"))(nil, nil, p) ||
+		!auth.AllRoles()(nil, nil, p) || auth.AnyRole()(nil, nil, p) {
+		t.Errorf("All failed")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package basic
+
+import (
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// CreateDecor creates a decorator that authenticates the requests with the
+// HTTP Basic credentials of their Authorization header, refer to RFC 7617.
+// The credentials are verified by the store, and the auth.Principal it
+// returns is stored in the Ctx with the Basic scheme.
+// The requests without valid credentials get a 401 with the Basic
+// challenge of the realm, through respond.CreateDecor that must be outer.
+// Usage example:
+/*
+	users, err := auth.LoadHtpasswd("/etc/golight/htpasswd")
+	if err != nil {
+		log.Fatal(err)
+	}
+	admin := mux.Group("/admin", decorator.DecoratorChain{
+		basic.CreateDecor(users, "admin"), respond.CreateDecor(),
+	})
+*/
+func CreateDecor(store auth.Store, realm string) decorator.Decorator {
+	challenge := auth.Challenge("Basic", "realm", realm, "charset", "UTF-8")
+	return decorator.Named("basic", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			user, pw, ok := h.R.BasicAuth()
+			if !ok {
+				return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: "missing credentials"})
+			}
+			p, err := store.Authenticate(h.R.Context(), user, pw)
+			if err != nil {
+				h.Err = err
+				return c
+			}
+			if p == nil {
+				return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: "invalid credentials"})
+			}
+			if p.Scheme == "" {
+				//copy, the Principal belongs to the store
+				pp := *p
+				pp.Scheme = "Basic"
+				p = &pp
+			}
+			c = auth.WithPrincipal(c, p)
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package basic_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/decorator/auth/basic"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Http request handler that responds with the principal
+var thPrincipal = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	p, _ := auth.GetPrincipal(c)
+	h.Resp.Message = p.Scheme + " " + p.Subject
+	return c
+})
+
+//Test cases for the Basic decorator
+func TestBasic(t *testing.T) {
+	h := decorator.Decorate(thPrincipal, basic.CreateDecor(auth.Users(map[string]string{"alice": "pw"}), "tools"), respond.CreateDecor())
+	const challenge = `Basic realm="tools", charset="UTF-8"`
+	testCases := []struct {
+		user, pw  string
+		set       bool
+		code      int
+		body      string
+		challenge string
+	}{
+		{"alice", "pw", true, 200, `{"code":200,"message":"Basic alice"}`, ""},
+		{"alice", "bad", true, 401, `{"code":401,"message":"auth: invalid credentials"}`, challenge},
+		{"", "", false, 401, `{"code":401,"message":"auth: missing credentials"}`, challenge},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tc.set {
+			r.SetBasicAuth(tc.user, tc.pw)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.body || w.Header().Get("WWW-Authenticate") != tc.challenge {
+			t.Errorf("%d. Basic failed, got: %d %s %q", i, w.Code, w.Body.String(), w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestBasicStoreError(t *testing.T) {
+	store := auth.StoreFunc(func(ctx context.Context, id, secret string) (*auth.Principal, error) {
+		return nil, errors.New("db down")
+	})
+	h := decorator.Decorate(thPrincipal, basic.CreateDecor(store, "tools"), respond.CreateDecor())
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("alice", "pw")
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+	if w.Code != 500 || w.Header().Get("WWW-Authenticate") != "" {
+		t.Errorf("Basic store error failed, got: %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet resolves the key that verifies a token from the kid and the alg
+// of its header.
+// The keys are []byte for HS256, *rsa.PublicKey for RS256,
+// *ecdsa.PublicKey for ES256 and ed25519.PublicKey for EdDSA.
+// A *ValidationError, e.g. for an unknown kid, rejects the token with 401;
+// the other errors, e.g. a failed fetch of the keys, are server errors.
+type KeySet interface {
+	Key(ctx context.Context, kid, alg string) (interface{}, error)
+}
+
+// StaticKey is the KeySet of a single key, used whatever the kid.
+// Usage example:
+/*
+	cfg := jwt.Config{Keys: jwt.StaticKey([]byte(os.Getenv("JWT_SECRET")))}
+*/
+func StaticKey(key interface{}) KeySet {
+	return staticKey{key}
+}
+
+type staticKey struct{ key interface{} }
+
+func (sk staticKey) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	return sk.key, nil
+}
+
+// JWK is a JSON Web Key, refer to RFC 7517.
+type JWK struct {
+	Kid string      // the key id
+	Alg string      // the algorithm of the key, empty if not set
+	Use string      // sig or enc
+	Key interface{} // the key, refer to KeySet for the types
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses the JSON Web Key Set. The keys of unsupported types
+// are skipped, so that a provider adding new key types does not break it.
+func ParseJWKS(b []byte) ([]JWK, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("jwt: invalid JWKS: %v", err)
+	}
+	keys := make([]JWK, 0, len(set.Keys))
+	for _, rk := range set.Keys {
+		key, err := rk.key()
+		if err != nil {
+			return nil, fmt.Errorf("jwt: invalid JWK %q: %v", rk.Kid, err)
+		}
+		if key != nil {
+			keys = append(keys, JWK{Kid: rk.Kid, Alg: rk.Alg, Use: rk.Use, Key: key})
+		}
+	}
+	return keys, nil
+}
+
+func (rk *rawJWK) key() (interface{}, error) {
+	switch {
+	case rk.Kty == "RSA":
+		n, err := decodeInt(rk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(rk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case rk.Kty == "EC" && rk.Crv == "P-256":
+		x, err := decodeInt(rk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(rk.Y)
+		if err != nil {
+			return nil, err
+		}
+		pk := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !pk.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on the P-256 curve")
+		}
+		return pk, nil
+	case rk.Kty == "OKP" && rk.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(rk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case rk.Kty == "oct":
+		k, err := base64.RawURLEncoding.DecodeString(rk.K)
+		if err != nil || len(k) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return k, nil
+	}
+	return nil, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWKSOptions configures the JWKS caching.
+type JWKSOptions struct {
+	// RefreshInterval is how long the keys are cached, 1 hour if 0.
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits how often an unknown kid reloads the keys,
+	// so that forged tokens can not hammer the source. 1 minute if 0.
+	MinRefreshInterval time.Duration
+	// FetchTimeout limits a load of the keys, 10 seconds if 0.
+	FetchTimeout time.Duration
+}
+
+// JWKS is the KeySet of a JSON Web Key Set loaded from a file or an URL.
+// The keys are cached for the RefreshInterval, and reloaded earlier when a
+// token has an unknown kid, so that the key rotations are picked up.
+// The cached keys are kept when a reload fails.
+// It is safe for concurrent use. The keys are loaded in the background,
+// once for all the concurrent requests and independently of their
+// contexts, so a slow source never blocks the tokens of the cached keys.
+type JWKS struct {
+	load func(ctx context.Context) ([]byte, error)
+	opts JWKSOptions
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	keys    []JWK
+	loaded  time.Time // when the keys were last loaded
+	tried   time.Time // when a load was last tried
+	lastErr error
+	loading chan struct{} // closed when the load in progress is done
+}
+
+// NewJWKS creates a JWKS from the loader of the JSON Web Key Set.
+func NewJWKS(load func(ctx context.Context) ([]byte, error), opts JWKSOptions) *JWKS {
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = time.Minute
+	}
+	if opts.FetchTimeout <= 0 {
+		opts.FetchTimeout = 10 * time.Second
+	}
+	return &JWKS{load: load, opts: opts, now: time.Now}
+}
+
+// NewJWKSFile creates a JWKS loaded from the local file.
+func NewJWKSFile(path string, opts JWKSOptions) *JWKS {
+	return NewJWKS(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}, opts)
+}
+
+// NewJWKSURL creates a JWKS fetched from the URL with the client,
+// http.DefaultClient if nil.
+// Usage example:
+/*
+	keys := jwt.NewJWKSURL("https://auth.example.com/.well-known/jwks.json", nil, jwt.JWKSOptions{})
+	cfg := jwt.Config{Keys: keys, Issuer: "https://auth.example.com/", Audience: "orders"}
+*/
+func NewJWKSURL(url string, client *http.Client, opts JWKSOptions) *JWKS {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NewJWKS(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("jwt: fetching %s: %s", url, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}, opts)
+}
+
+// Key implements KeySet.
+func (ks *JWKS) Key(ctx context.Context, kid, alg string) (interface{}, error) {
+	now := ks.now()
+	ks.mu.RLock()
+	key, ok := ks.find(kid, alg)
+	stale := ks.loaded.IsZero() || now.Sub(ks.loaded) >= ks.opts.RefreshInterval
+	ks.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	//Reload the stale keys, or the keys that may have been rotated
+	if err := ks.reload(ctx, now); err != nil {
+		return nil, err
+	}
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if key, ok := ks.find(kid, alg); ok {
+		return key, nil
+	}
+	if ks.keys == nil && ks.lastErr != nil {
+		return nil, ks.lastErr
+	}
+	//the keys are loaded but none matches, the token is at fault
+	return nil, invalid("no key for kid %q", kid)
+}
+
+// reload loads the keys and waits for them, unless a load was tried
+// within the MinRefreshInterval. A load in progress is waited for instead
+// of starting another one. It only fails when ctx is done first.
+func (ks *JWKS) reload(ctx context.Context, now time.Time) error {
+	ks.mu.Lock()
+	done := ks.loading
+	if done == nil {
+		if !ks.tried.IsZero() && now.Sub(ks.tried) < ks.opts.MinRefreshInterval {
+			ks.mu.Unlock()
+			return nil
+		}
+		ks.tried = now
+		done = make(chan struct{})
+		ks.loading = done
+		go ks.fetch(now, done)
+	}
+	ks.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch loads the keys on a context of its own, so that the request that
+// started the load can not cancel it. The cached keys are kept on failure.
+func (ks *JWKS) fetch(now time.Time, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), ks.opts.FetchTimeout)
+	defer cancel()
+	b, err := ks.load(ctx)
+	var keys []JWK
+	if err == nil {
+		keys, err = ParseJWKS(b)
+	}
+
+	ks.mu.Lock()
+	if err == nil {
+		ks.keys, ks.loaded = keys, now
+	}
+	ks.lastErr = err
+	ks.loading = nil
+	ks.mu.Unlock()
+	close(done)
+}
+
+func (ks *JWKS) find(kid, alg string) (interface{}, bool) {
+	for _, k := range ks.keys {
+		if (kid == "" || k.Kid == kid) && (k.Alg == "" || k.Alg == alg) && k.Use != "enc" && keyMatches(k.Key, alg) {
+			return k.Key, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,206 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator/auth/jwt"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func jwk(kid string, key interface{}) map[string]string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(k.X.FillBytes(make([]byte, 32))), "y": b64(k.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(k)}
+	case []byte:
+		return map[string]string{"kty": "oct", "kid": kid, "k": b64(k), "alg": "HS256"}
+	}
+	return nil
+}
+
+func jwks(keys ...map[string]string) []byte {
+	b, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	return b
+}
+
+//Test cases for the JWKS parsing
+func TestParseJWKS(t *testing.T) {
+	b := jwks(jwk("rsa", &rsaKey.PublicKey), jwk("ec", &ecKey.PublicKey), jwk("ed", edKey.Public()),
+		jwk("hs", hsKey), map[string]string{"kty": "EC", "crv": "P-521", "kid": "skipped"})
+	keys, err := jwt.ParseJWKS(b)
+	if err != nil || len(keys) != 4 {
+		t.Fatalf("ParseJWKS failed, got: %v %d keys", err, len(keys))
+	}
+	if !rsaKey.PublicKey.Equal(keys[0].Key) || !ecKey.PublicKey.Equal(keys[1].Key) ||
+		!edKey.Public().(ed25519.PublicKey).Equal(keys[2].Key) || string(keys[3].Key.([]byte)) != string(hsKey) {
+		t.Errorf("ParseJWKS keys failed, got: %+v", keys)
+	}
+
+	bad := jwks(map[string]string{"kty": "EC", "crv": "P-256", "kid": "bad", "x": b64([]byte{1}), "y": b64([]byte{2})})
+	if _, err := jwt.ParseJWKS(bad); err == nil {
+		t.Errorf("ParseJWKS accepted a point not on the curve")
+	}
+}
+
+func TestJWKSFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	os.WriteFile(path, jwks(jwk("ec", &ecKey.PublicKey)), 0600)
+
+	cfg := &jwt.Config{Keys: jwt.NewJWKSFile(path, jwt.JWKSOptions{}), Now: func() time.Time { return now }}
+	tok, _ := jwt.Sign(jwt.ES256, "ec", claims(nil), ecKey)
+	var cl testClaims
+	if err := jwt.Parse(context.Background(), cfg, tok, &cl); err != nil {
+		t.Errorf("JWKS file failed, got: %v", err)
+	}
+	//the kid selects the key
+	tok, _ = jwt.Sign(jwt.ES256, "other", claims(nil), ecKey)
+	var ve *jwt.ValidationError
+	if err := jwt.Parse(context.Background(), cfg, tok, &cl); !errors.As(err, &ve) || err.Error() != `jwt: no key for kid "other"` {
+		t.Errorf("JWKS unknown kid failed, got: %v", err)
+	}
+}
+
+func TestJWKSURLRotation(t *testing.T) {
+	var fetches int32
+	current := jwks(jwk("k1", &rsaKey.PublicKey))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(current)
+	}))
+	defer ts.Close()
+
+	ks := jwt.NewJWKSURL(ts.URL, nil, jwt.JWKSOptions{MinRefreshInterval: time.Nanosecond})
+	cfg := &jwt.Config{Keys: ks, Now: func() time.Time { return now }}
+	var cl testClaims
+	tok1, _ := jwt.Sign(jwt.RS256, "k1", claims(nil), rsaKey)
+	for i := 0; i < 3; i++ {
+		if err := jwt.Parse(context.Background(), cfg, tok1, &cl); err != nil {
+			t.Fatalf("JWKS URL failed, got: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("JWKS URL caching failed, got: %d fetches", n)
+	}
+
+	//rotate to k2, the unknown kid reloads the keys
+	current = jwks(jwk("k2", edKey.Public()))
+	tok2, _ := jwt.Sign(jwt.EdDSA, "k2", claims(nil), edKey)
+	if err := jwt.Parse(context.Background(), cfg, tok2, &cl); err != nil {
+		t.Errorf("JWKS URL rotation failed, got: %v", err)
+	}
+	if err := jwt.Parse(context.Background(), cfg, tok1, &cl); err == nil {
+		t.Errorf("JWKS URL kept the rotated out key")
+	}
+}
+
+func TestJWKSThrottle(t *testing.T) {
+	var fetches int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(jwks(jwk("k1", &rsaKey.PublicKey)))
+	}))
+	defer ts.Close()
+
+	cfg := &jwt.Config{Keys: jwt.NewJWKSURL(ts.URL, nil, jwt.JWKSOptions{}), Now: func() time.Time { return now }}
+	var cl testClaims
+	for i := 0; i < 5; i++ {
+		tok, _ := jwt.Sign(jwt.RS256, "forged", claims(nil), rsaKey)
+		jwt.Parse(context.Background(), cfg, tok, &cl)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("JWKS throttle failed, got: %d fetches", n)
+	}
+}
+
+func TestJWKSConcurrentLoad(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	ks := jwt.NewJWKS(func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return jwks(jwk("k1", &rsaKey.PublicKey)), ctx.Err()
+	}, jwt.JWKSOptions{MinRefreshInterval: time.Nanosecond})
+
+	//a canceled request neither cancels the load nor caches its error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ks.Key(ctx, "k1", jwt.RS256); err != context.Canceled {
+		t.Errorf("JWKS canceled request failed, got: %v", err)
+	}
+
+	//the concurrent requests wait for the same load
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ks.Key(context.Background(), "k1", jwt.RS256)
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("JWKS concurrent load failed, got: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("JWKS loads not deduplicated, got: %d fetches", n)
+	}
+}
+
+func TestJWKSSlowReload(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var fetches int32
+	ks := jwt.NewJWKS(func(ctx context.Context) ([]byte, error) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-release
+		}
+		return jwks(jwk("k1", &rsaKey.PublicKey)), nil
+	}, jwt.JWKSOptions{MinRefreshInterval: time.Nanosecond})
+	if _, err := ks.Key(context.Background(), "k1", jwt.RS256); err != nil {
+		t.Fatal(err)
+	}
+
+	//a forged kid reloads from the slow source
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	go ks.Key(ctx, "forged", jwt.RS256)
+	time.Sleep(5 * time.Millisecond)
+
+	//the cached kid does not wait for it
+	start := time.Now()
+	if _, err := ks.Key(context.Background(), "k1", jwt.RS256); err != nil || time.Since(start) > 10*time.Millisecond {
+		t.Errorf("JWKS cached key blocked by the reload, got: %v after %v", err, time.Since(start))
+	}
+}
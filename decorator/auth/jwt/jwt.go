@@ -0,0 +1,434 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jwt authenticates the requests with the JSON Web Tokens of their
+// Authorization: Bearer header, refer to RFC 7519.
+// The HS256, RS256, ES256 and EdDSA (Ed25519) algorithms are supported.
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// The supported algorithms
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// NumericDate is a JWT date, the seconds since the Unix epoch.
+// Zero means the claim is not set.
+type NumericDate int64
+
+// UnmarshalJSON accepts the fractional dates.
+func (nd *NumericDate) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("jwt: invalid date %s", b)
+	}
+	*nd = NumericDate(math.Floor(f))
+	return nil
+}
+
+// Time returns the date as a time.Time.
+func (nd NumericDate) Time() time.Time {
+	return time.Unix(int64(nd), 0)
+}
+
+// Audience is the aud claim, a string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var strs []string
+	if err := json.Unmarshal(b, &strs); err != nil {
+		return fmt.Errorf("jwt: invalid audience %s", b)
+	}
+	*a = strs
+	return nil
+}
+
+// RegisteredClaims are the claims registered by RFC 7519. Embed it into
+// the application claims:
+/*
+	type Claims struct {
+		jwt.RegisteredClaims
+		Tenant string `json:"tenant"`
+	}
+*/
+type RegisteredClaims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+}
+
+// Registered returns the registered claims, so that the structs embedding
+// RegisteredClaims implement Claims.
+func (rc *RegisteredClaims) Registered() *RegisteredClaims {
+	return rc
+}
+
+// Claims are the claims a token is decoded into.
+type Claims interface {
+	Registered() *RegisteredClaims
+}
+
+// Config configures the token validation.
+type Config struct {
+	Keys       KeySet        // the keys that verify the tokens
+	Algorithms []string      // the accepted algorithms, all the supported ones if empty
+	Issuer     string        // the required iss, not checked if empty
+	Audience   string        // the audience the aud must contain, not checked if empty
+	Leeway     time.Duration // the clock skew allowed checking exp and nbf
+	Realm      string        // the realm of the WWW-Authenticate challenge
+	Now        func() time.Time
+}
+
+// ValidationError is the error of an invalid token.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "jwt: " + e.Message
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Parse verifies the signature of the compact token, decodes its payload
+// into claims and validates the exp, nbf, iss and aud claims.
+// The errors of invalid tokens are *ValidationError, the other errors
+// come from the KeySet.
+func Parse(ctx context.Context, cfg *Config, token string, claims Claims) error {
+	_, err := parse(ctx, cfg, token, claims)
+	return err
+}
+
+func parse(ctx context.Context, cfg *Config, token string, claims Claims) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+	hb, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalid("malformed header")
+	}
+	var hd header
+	if err := json.Unmarshal(hb, &hd); err != nil {
+		return nil, invalid("malformed header")
+	}
+	if !accepted(cfg.Algorithms, hd.Alg) {
+		return nil, invalid("algorithm %q not accepted", hd.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed signature")
+	}
+
+	key, err := cfg.Keys.Key(ctx, hd.Kid, hd.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(hd.Alg, key, []byte(token[:len(parts[0])+1+len(parts[1])]), sig); err != nil {
+		return nil, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, invalid("malformed payload")
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if err := dec.Decode(claims); err != nil {
+		return nil, invalid("malformed claims: %v", err)
+	}
+	return payload, validate(cfg, claims.Registered())
+}
+
+func accepted(algs []string, alg string) bool {
+	if len(algs) == 0 {
+		algs = []string{HS256, RS256, ES256, EdDSA}
+	}
+	for _, a := range algs {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// keyMatches reports whether the key is of the type of the algorithm, so
+// that e.g. an RSA public key can never be used as an HMAC secret.
+func keyMatches(key interface{}, alg string) bool {
+	switch key.(type) {
+	case []byte:
+		return alg == HS256
+	case *rsa.PublicKey:
+		return alg == RS256
+	case *ecdsa.PublicKey:
+		return alg == ES256
+	case ed25519.PublicKey:
+		return alg == EdDSA
+	}
+	return false
+}
+
+func verify(alg string, key interface{}, signed, sig []byte) error {
+	if !keyMatches(key, alg) {
+		return invalid("key does not match algorithm %q", alg)
+	}
+	ok := false
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write(signed)
+		ok = hmac.Equal(sig, mac.Sum(nil))
+	case RS256:
+		sum := sha256.Sum256(signed)
+		ok = rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, sum[:], sig) == nil
+	case ES256:
+		if len(sig) == 64 {
+			sum := sha256.Sum256(signed)
+			r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+			ok = ecdsa.Verify(key.(*ecdsa.PublicKey), sum[:], r, s)
+		}
+	case EdDSA:
+		ok = ed25519.Verify(key.(ed25519.PublicKey), signed, sig)
+	}
+	if !ok {
+		return invalid("invalid signature")
+	}
+	return nil
+}
+
+func validate(cfg *Config, rc *RegisteredClaims) error {
+	now := time.Now()
+	if cfg.Now != nil {
+		now = cfg.Now()
+	}
+	if rc.ExpiresAt != 0 && !now.Before(rc.ExpiresAt.Time().Add(cfg.Leeway)) {
+		return invalid("token expired")
+	}
+	if rc.NotBefore != 0 && now.Add(cfg.Leeway).Before(rc.NotBefore.Time()) {
+		return invalid("token not valid yet")
+	}
+	if cfg.Issuer != "" && rc.Issuer != cfg.Issuer {
+		return invalid("invalid issuer")
+	}
+	if cfg.Audience != "" {
+		found := false
+		for _, aud := range rc.Audience {
+			found = found || aud == cfg.Audience
+		}
+		if !found {
+			return invalid("invalid audience")
+		}
+	}
+	return nil
+}
+
+// Sign signs the claims into a compact token, mostly for the tests and
+// the services issuing their own tokens. The key is []byte for HS256,
+// *rsa.PrivateKey for RS256, *ecdsa.PrivateKey for ES256 and
+// ed25519.PrivateKey for EdDSA.
+func Sign(alg, kid string, claims interface{}, key interface{}) (string, error) {
+	hb, err := json.Marshal(header{Alg: alg, Kid: kid, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	pb, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(pb)
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		if alg != HS256 {
+			break
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg != RS256 {
+			break
+		}
+		sum := sha256.Sum256([]byte(signed))
+		sig, err = rsa.SignPKCS1v15(nil, k, crypto.SHA256, sum[:])
+	case *ecdsa.PrivateKey:
+		if alg != ES256 {
+			break
+		}
+		sum := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		if r, s, err = ecdsa.Sign(rand.Reader, k, sum[:]); err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	case ed25519.PrivateKey:
+		if alg != EdDSA {
+			break
+		}
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	if err != nil {
+		return "", err
+	}
+	if sig == nil {
+		return "", fmt.Errorf("jwt: key %T does not match algorithm %q", key, alg)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Internal typed key
+var claimsKey = ghttp.NewKey[interface{}]("jwt.claims")
+
+// GetClaims returns the claims of the request token and whether they were
+// found. T is the claims type CreateDecor was instantiated with.
+func GetClaims[T any](c ghttp.Ctx) (*T, bool) {
+	cl, _ := claimsKey.From(c)
+	t, ok := cl.(*T)
+	return t, ok
+}
+
+// scopeClaims are the standard claims the Principal roles, scopes and
+// permissions are read from: the space separated scope of RFC 8693, or the
+// scp array, the roles array and the permissions array.
+type scopeClaims struct {
+	Scope       string   `json:"scope"`
+	Scp         []string `json:"scp"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// CreateDecor creates a decorator that authenticates the requests with the
+// Bearer token of their Authorization header.
+// The claims of a valid token are decoded into a new T, stored in the Ctx
+// for GetClaims, and the auth.Principal is stored with the sub, the roles,
+// the scope or scp and the permissions claims.
+// The requests without a valid token get a 401 with the WWW-Authenticate
+// challenge of RFC 6750, through respond.CreateDecor that must be outer.
+// Usage example:
+/*
+	type Claims struct {
+		jwt.RegisteredClaims
+		Tenant string `json:"tenant"`
+	}
+
+	cfg := jwt.Config{
+		Keys:     jwt.NewJWKSURL("https://auth.example.com/.well-known/jwks.json", nil, jwt.JWKSOptions{}),
+		Issuer:   "https://auth.example.com/",
+		Audience: "orders",
+		Leeway:   30 * time.Second,
+	}
+	h := decorator.Decorate(hdl, jwt.CreateDecor[Claims](cfg), respond.CreateDecor())
+
+	//in the handler
+	claims, _ := jwt.GetClaims[Claims](c)
+*/
+func CreateDecor[T any, PT interface {
+	*T
+	Claims
+}](cfg Config) decorator.Decorator {
+	if cfg.Keys == nil {
+		panic("jwt: nil Config.Keys")
+	}
+	fail := func(c ghttp.Ctx, h *ghttp.Http, code, msg string) ghttp.Ctx {
+		challenge := auth.Challenge("Bearer", "realm", cfg.Realm)
+		if code != "" {
+			challenge = auth.Challenge("Bearer", "realm", cfg.Realm, "error", code, "error_description", msg)
+		}
+		return auth.Fail(c, h, &auth.Error{Challenge: challenge, Message: msg})
+	}
+
+	return decorator.Named("jwt", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			token, ok := bearer(h.R.Header.Get("Authorization"))
+			if !ok {
+				//RFC 6750 3.1, no error code when the token is missing
+				return fail(c, h, "", "missing bearer token")
+			}
+
+			claims := PT(new(T))
+			payload, err := parse(h.R.Context(), &cfg, token, claims)
+			if err != nil {
+				var ve *ValidationError
+				if !errors.As(err, &ve) {
+					//the key set failed, do not blame the client
+					h.Err = err
+					return c
+				}
+				return fail(c, h, "invalid_token", ve.Message)
+			}
+
+			var sc scopeClaims
+			json.Unmarshal(payload, &sc)
+			scopes := sc.Scp
+			if sc.Scope != "" {
+				scopes = strings.Fields(sc.Scope)
+			}
+			c = claimsKey.With(c, claims)
+			c = auth.WithPrincipal(c, &auth.Principal{
+				Subject:     claims.Registered().Subject,
+				Scheme:      "Bearer",
+				Roles:       sc.Roles,
+				Scopes:      scopes,
+				Permissions: sc.Permissions,
+				Claims:      claims,
+			})
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// bearer returns the token of the Authorization header.
+func bearer(authz string) (string, bool) {
+	scheme, token, ok := strings.Cut(authz, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,217 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/auth"
+	"github.com/dlmc/golight/decorator/auth/jwt"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+type testClaims struct {
+	jwt.RegisteredClaims
+	Tenant string   `json:"tenant,omitempty"`
+	Scope  string   `json:"scope,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+var (
+	hsKey       = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ = ed25519.GenerateKey(rand.Reader)
+	now         = time.Unix(1700000000, 0)
+)
+
+func sign(t *testing.T, alg string, key interface{}, cl testClaims) string {
+	tok, err := jwt.Sign(alg, "", cl, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func claims(mod func(*testClaims)) testClaims {
+	cl := testClaims{RegisteredClaims: jwt.RegisteredClaims{
+		Issuer: "https://auth.example.com/", Subject: "u1", Audience: jwt.Audience{"orders"},
+		ExpiresAt: jwt.NumericDate(now.Add(time.Hour).Unix()),
+	}}
+	if mod != nil {
+		mod(&cl)
+	}
+	return cl
+}
+
+// Test cases for the algorithms
+func TestParseAlgorithms(t *testing.T) {
+	testCases := []struct {
+		alg     string
+		signKey interface{}
+		verify  interface{}
+	}{
+		{jwt.HS256, hsKey, hsKey},
+		{jwt.RS256, rsaKey, &rsaKey.PublicKey},
+		{jwt.ES256, ecKey, &ecKey.PublicKey},
+		{jwt.EdDSA, edKey, edKey.Public()},
+	}
+	for i, tc := range testCases {
+		cfg := &jwt.Config{Keys: jwt.StaticKey(tc.verify), Now: func() time.Time { return now }}
+		tok := sign(t, tc.alg, tc.signKey, claims(nil))
+		var cl testClaims
+		if err := jwt.Parse(context.Background(), cfg, tok, &cl); err != nil || cl.Subject != "u1" {
+			t.Errorf("%d. Parse %s failed, got: %v %+v", i, tc.alg, err, cl)
+		}
+
+		//tamper the payload
+		parts := strings.Split(tok, ".")
+		forged := parts[0] + "." + strings.Split(sign(t, jwt.HS256, hsKey, claims(func(c *testClaims) { c.Subject = "admin" })), ".")[1] + "." + parts[2]
+		if err := jwt.Parse(context.Background(), cfg, forged, &cl); err == nil {
+			t.Errorf("%d. Parse %s accepted a forged token", i, tc.alg)
+		}
+	}
+}
+
+func TestParseClaims(t *testing.T) {
+	cfg := &jwt.Config{
+		Keys:     jwt.StaticKey(hsKey),
+		Issuer:   "https://auth.example.com/",
+		Audience: "orders",
+		Leeway:   30 * time.Second,
+		Now:      func() time.Time { return now },
+	}
+	testCases := []struct {
+		mod func(*testClaims)
+		err string
+	}{
+		{nil, ""},
+		{func(c *testClaims) { c.ExpiresAt = jwt.NumericDate(now.Add(-10 * time.Second).Unix()) }, ""},
+		{func(c *testClaims) { c.ExpiresAt = jwt.NumericDate(now.Add(-time.Minute).Unix()) }, "jwt: token expired"},
+		{func(c *testClaims) { c.NotBefore = jwt.NumericDate(now.Add(10 * time.Second).Unix()) }, ""},
+		{func(c *testClaims) { c.NotBefore = jwt.NumericDate(now.Add(time.Minute).Unix()) }, "jwt: token not valid yet"},
+		{func(c *testClaims) { c.Issuer = "https://evil.com/" }, "jwt: invalid issuer"},
+		{func(c *testClaims) { c.Audience = jwt.Audience{"billing", "orders"} }, ""},
+		{func(c *testClaims) { c.Audience = jwt.Audience{"billing"} }, "jwt: invalid audience"},
+	}
+	for i, tc := range testCases {
+		var cl testClaims
+		err := jwt.Parse(context.Background(), cfg, sign(t, jwt.HS256, hsKey, claims(tc.mod)), &cl)
+		if got := errString(err); got != tc.err {
+			t.Errorf("%d. Parse claims failed, got: %q, want: %q", i, got, tc.err)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	cfg := &jwt.Config{Keys: jwt.StaticKey(&rsaKey.PublicKey), Algorithms: []string{jwt.RS256}, Now: func() time.Time { return now }}
+	var cl testClaims
+
+	//alg none
+	none := "eyJhbGciOiJub25lIn0." + strings.Split(sign(t, jwt.HS256, hsKey, claims(nil)), ".")[1] + "."
+	if err := jwt.Parse(context.Background(), cfg, none, &cl); errString(err) != `jwt: algorithm "none" not accepted` {
+		t.Errorf("Parse accepted alg none, got: %v", err)
+	}
+	//the RSA public key used as an HMAC secret
+	cfg.Algorithms = nil
+	if err := jwt.Parse(context.Background(), cfg, sign(t, jwt.HS256, hsKey, claims(nil)), &cl); errString(err) != `jwt: key does not match algorithm "HS256"` {
+		t.Errorf("Parse accepted the algorithm confusion, got: %v", err)
+	}
+	if err := jwt.Parse(context.Background(), cfg, "a.b", &cl); errString(err) != "jwt: malformed token" {
+		t.Errorf("Parse accepted a malformed token, got: %v", err)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Test cases for the decorator
+func TestCreateDecor(t *testing.T) {
+	cfg := jwt.Config{Keys: jwt.StaticKey(hsKey), Audience: "orders", Realm: "api", Now: func() time.Time { return now }}
+	hdl := ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		cl, ok := jwt.GetClaims[testClaims](c)
+		p, _ := auth.GetPrincipal(c)
+		if !ok || p.Claims != cl {
+			t.Errorf("CreateDecor claims not found")
+		}
+		h.Resp.Data = []string{cl.Tenant, p.Subject, p.Scheme, strings.Join(p.Scopes, "+"), strings.Join(p.Roles, "+")}
+		return c
+	})
+	h := decorator.Decorate(hdl, jwt.CreateDecor[testClaims](cfg), respond.CreateDecor())
+
+	testCases := []struct {
+		authz     string
+		code      int
+		challenge string
+		body      string
+	}{
+		{"Bearer " + sign(t, jwt.HS256, hsKey, claims(func(c *testClaims) { c.Tenant, c.Scope, c.Roles = "t1", "read write", []string{"admin"} })),
+			200, "", `{"code":200,"data":["t1","u1","Bearer","read+write","admin"]}`},
+		{"", 401, `Bearer realm="api"`, `{"code":401,"message":"auth: missing bearer token"}`},
+		{"Basic dTpw", 401, `Bearer realm="api"`, `{"code":401,"message":"auth: missing bearer token"}`},
+		{"Bearer " + sign(t, jwt.HS256, hsKey, claims(func(c *testClaims) { c.ExpiresAt = 1 })),
+			401, `Bearer realm="api", error="invalid_token", error_description="token expired"`, `{"code":401,"message":"auth: token expired"}`},
+	}
+	for i, tc := range testCases {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tc.authz != "" {
+			r.Header.Set("Authorization", tc.authz)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+		if w.Code != tc.code || w.Header().Get("WWW-Authenticate") != tc.challenge || strings.TrimSpace(w.Body.String()) != tc.body {
+			t.Errorf("%d. CreateDecor failed, got: %d %q %s", i, w.Code, w.Header().Get("WWW-Authenticate"), w.Body.String())
+		}
+	}
+}
+
+func TestCreateDecorKeySet(t *testing.T) {
+	loaded := jwt.NewJWKS(func(ctx context.Context) ([]byte, error) {
+		return jwks(jwk("ec", &ecKey.PublicKey)), nil
+	}, jwt.JWKSOptions{})
+	failed := jwt.NewJWKS(func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("jwt: fetching keys: 503 Service Unavailable")
+	}, jwt.JWKSOptions{})
+
+	testCases := []struct {
+		keys jwt.KeySet
+		kid  string
+		code int
+	}{
+		{loaded, "ec", 200},
+		//a forged kid is the client's fault, not a server error
+		{loaded, "forged", 401},
+		{failed, "ec", 500},
+	}
+	for i, tc := range testCases {
+		cfg := jwt.Config{Keys: tc.keys, Now: func() time.Time { return now }}
+		h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx { return c }),
+			jwt.CreateDecor[testClaims](cfg), respond.CreateDecor())
+		tok, _ := jwt.Sign(jwt.ES256, tc.kid, claims(nil), ecKey)
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+tok)
+		w := httptest.NewRecorder()
+		h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+		if w.Code != tc.code {
+			t.Errorf("%d. CreateDecor key set failed, got: %d %s", i, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Store verifies the credentials of the Basic and API key decorators.
+// The Basic decorator passes the user name and the password, the API key
+// decorator the key id, empty unless the keys are split, and the key.
+// It returns nil and no error for invalid credentials, the errors are
+// reserved to the store failures.
+type Store interface {
+	Authenticate(ctx context.Context, id, secret string) (*Principal, error)
+}
+
+// StoreFunc is a custom Store function.
+// Usage example:
+/*
+	store := auth.StoreFunc(func(ctx context.Context, id, secret string) (*auth.Principal, error) {
+		u, err := db.UserByAPIKey(ctx, secret)
+		if err != nil || u == nil {
+			return nil, err
+		}
+		return &auth.Principal{Subject: u.ID, Roles: u.Roles}, nil
+	})
+*/
+type StoreFunc func(ctx context.Context, id, secret string) (*Principal, error)
+
+// Authenticate implements Store.
+func (f StoreFunc) Authenticate(ctx context.Context, id, secret string) (*Principal, error) {
+	return f(ctx, id, secret)
+}
+
+// Users returns the Store of the user name / password map, for the
+// Basic decorator. The passwords are compared in constant time.
+func Users(users map[string]string) Store {
+	digests := make(map[string][sha256.Size]byte, len(users))
+	for u, pw := range users {
+		digests[u] = sha256.Sum256([]byte(pw))
+	}
+	return StoreFunc(func(ctx context.Context, id, secret string) (*Principal, error) {
+		want, ok := digests[id]
+		got := sha256.Sum256([]byte(secret))
+		//compare even for unknown users, so they take the same time
+		if subtle.ConstantTimeCompare(got[:], want[:]) == 1 && ok {
+			return &Principal{Subject: id}, nil
+		}
+		return nil, nil
+	})
+}
+
+// APIKeys returns the Store of the API key / principal map, for the API
+// key decorator. The key is compared in constant time against all the
+// keys, so the response time does not tell how close a guess is.
+// Usage example:
+/*
+	store := auth.APIKeys(map[string]*auth.Principal{
+		os.Getenv("PARTNER_A_KEY"): {Subject: "partner-a", Scopes: []string{"orders:read"}},
+	})
+*/
+func APIKeys(keys map[string]*Principal) Store {
+	type entry struct {
+		digest [sha256.Size]byte
+		p      *Principal
+	}
+	entries := make([]entry, 0, len(keys))
+	for k, p := range keys {
+		entries = append(entries, entry{sha256.Sum256([]byte(k)), p})
+	}
+	return StoreFunc(func(ctx context.Context, id, secret string) (*Principal, error) {
+		got := sha256.Sum256([]byte(secret))
+		var found *Principal
+		for i := range entries {
+			if subtle.ConstantTimeCompare(got[:], entries[i].digest[:]) == 1 {
+				found = entries[i].p
+			}
+		}
+		if found == nil {
+			return nil, nil
+		}
+		p := *found
+		return &p, nil
+	})
+}
+
+// Htpasswd is the Store of an htpasswd file with bcrypt hashes, as
+// created by `htpasswd -B`. The id is the user name for the Basic
+// decorator, or the key id for the API key decorator with split keys.
+type Htpasswd struct {
+	hashes map[string][]byte
+}
+
+// A valid bcrypt hash of the default cost compared for the unknown users,
+// so they take the same time as the known ones. It is precomputed, so the
+// importers do not pay for a bcrypt hash at startup.
+var dummyHash = []byte("$2a$10$31syMJhRONr3smAQo.rgoeJ700ibKR8qtZH51Cazjq7zh1nDunSUa")
+
+// ParseHtpasswd parses the htpasswd lines "user:hash". The empty lines
+// and the lines starting with # are skipped. Only the bcrypt hashes
+// ($2y$, $2a$, $2b$) are supported.
+func ParseHtpasswd(r io.Reader) (*Htpasswd, error) {
+	ht := &Htpasswd{hashes: map[string][]byte{}}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		user, hash, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("auth: htpasswd line %d: malformed", n)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("auth: htpasswd line %d: user %q: only bcrypt hashes are supported", n, user)
+		}
+		ht.hashes[user] = []byte(hash)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ht, nil
+}
+
+// LoadHtpasswd loads the htpasswd file, refer to ParseHtpasswd.
+func LoadHtpasswd(path string) (*Htpasswd, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseHtpasswd(f)
+}
+
+// Authenticate implements Store.
+func (ht *Htpasswd) Authenticate(ctx context.Context, id, secret string) (*Principal, error) {
+	hash, ok := ht.hashes[id]
+	if !ok {
+		hash = dummyHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(secret)) == nil && ok {
+		return &Principal{Subject: id}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func subject(t *testing.T, s auth.Store, id, secret string) string {
+	p, err := s.Authenticate(context.Background(), id, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil {
+		return ""
+	}
+	return p.Subject
+}
+
+//Test cases for the credential stores
+func TestUsers(t *testing.T) {
+	s := auth.Users(map[string]string{"alice": "s3cret"})
+	if subject(t, s, "alice", "s3cret") != "alice" || subject(t, s, "alice", "wrong") != "" ||
+		subject(t, s, "bob", "s3cret") != "" || subject(t, s, "bob", "") != "" {
+		t.Errorf("Users failed")
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	pa := &auth.Principal{Subject: "partner-a", Scopes: []string{"orders:read"}}
+	s := auth.APIKeys(map[string]*auth.Principal{"key-a": pa, "key-b": {Subject: "partner-b"}})
+	p, _ := s.Authenticate(context.Background(), "", "key-a")
+	if p == nil || p.Subject != "partner-a" || p == pa || !p.HasScope("orders:read") {
+		t.Errorf("APIKeys failed, got: %+v", p)
+	}
+	if subject(t, s, "", "key-b") != "partner-b" || subject(t, s, "", "key-c") != "" {
+		t.Errorf("APIKeys failed")
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	ht, err := auth.ParseHtpasswd(strings.NewReader("# users\n\nalice:" + string(hash) + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject(t, ht, "alice", "pw") != "alice" || subject(t, ht, "alice", "bad") != "" || subject(t, ht, "bob", "pw") != "" {
+		t.Errorf("Htpasswd failed")
+	}
+
+	testCases := []struct {
+		in, err string
+	}{
+		{"bob:$apr1$salt$hash", `auth: htpasswd line 1: user "bob": only bcrypt hashes are supported`},
+		{"\nnocolon", "auth: htpasswd line 2: malformed"},
+	}
+	for i, tc := range testCases {
+		if _, err := auth.ParseHtpasswd(strings.NewReader(tc.in)); err == nil || err.Error() != tc.err {
+			t.Errorf("%d. ParseHtpasswd failed, got: %v, want: %s", i, err, tc.err)
+		}
+	}
+}
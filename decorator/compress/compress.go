@@ -0,0 +1,229 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package compress compresses the responses with the content coding
+// negotiated from the Accept-Encoding header.
+// Only gzip and deflate are built in, as the standard library has no zstd
+// encoder. zstd, or br, is plugged in with Register and NewEncoder around
+// a third party encoder, e.g. github.com/klauspost/compress/zstd.
+package compress
+
+import (
+	"bufio"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// DefaultSkipTypes are the media types that are already compressed.
+// A type ending with / matches all its subtypes.
+var DefaultSkipTypes = []string{
+	"image/", "audio/", "video/", "font/woff", "font/woff2",
+	"application/gzip", "application/x-gzip", "application/zip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/x-bzip2",
+	"application/x-xz", "application/pdf", "application/wasm",
+}
+
+// Options configures CreateDecorWith.
+type Options struct {
+	MinSize   int        // the smaller bodies are not compressed, 1024 if 0
+	Encoders  []*Encoder // in preference order, the registered Encoders if nil
+	SkipTypes []string   // the media types not compressed, DefaultSkipTypes if nil
+}
+
+// CreateDecor creates a decorator that compresses the responses, refer to
+// CreateDecorWith for details.
+func CreateDecor() decorator.Decorator {
+	return CreateDecorWith(Options{})
+}
+
+// CreateDecorWith creates a decorator that compresses the responses with
+// the encoding negotiated from the Accept-Encoding header.
+// h.W is wrapped, so the inner decorators and the handler write as usual.
+// The bodies smaller than MinSize, of the media types already compressed
+// (SkipTypes, images but SVG), or with a Content-Encoding are sent as is.
+// Vary: Accept-Encoding is always set. The compressors are pooled.
+// Usage example:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), compress.CreateDecor(), logging.CreateDecor(lc))
+*/
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.MinSize == 0 {
+		opts.MinSize = 1024
+	}
+	if opts.Encoders == nil {
+		opts.Encoders = Encoders()
+	}
+	if opts.SkipTypes == nil {
+		opts.SkipTypes = DefaultSkipTypes
+	}
+	return decorator.Named("compress", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.Header().Add("Vary", "Accept-Encoding")
+			enc := negotiate(h.R.Header.Get("Accept-Encoding"), opts.Encoders)
+			if enc == nil || h.R.Method == http.MethodHead {
+				return next.ServeHTTPWithCtx(c, h)
+			}
+
+			cw := &compressWriter{ResponseWriter: h.W, enc: enc, opts: &opts}
+			orig := h.W
+			h.W = cw
+			defer func() {
+				h.W = orig
+				cw.close()
+			}()
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// compressWriter buffers the first MinSize bytes to decide whether to
+// compress the body.
+type compressWriter struct {
+	http.ResponseWriter
+	enc     *Encoder
+	opts    *Options
+	status  int
+	buf     []byte
+	decided bool
+	comp    Compressor // nil when the body is sent as is
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = code
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		if len(cw.buf)+len(b) < cw.opts.MinSize {
+			cw.buf = append(cw.buf, b...)
+			return len(b), nil
+		}
+		if err := cw.decide(true, b); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.comp != nil {
+		return cw.comp.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide writes the header, compressing if big is set and the response
+// can be compressed, then the buffered bytes and b.
+func (cw *compressWriter) decide(big bool, b []byte) error {
+	cw.decided = true
+	hd := cw.Header()
+	if big && cw.compressible(b) {
+		hd.Del("Content-Length")
+		hd.Set("Content-Encoding", cw.enc.name)
+		cw.comp = cw.enc.get(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	var w interface{ Write([]byte) (int, error) } = cw.ResponseWriter
+	if cw.comp != nil {
+		w = cw.comp
+	}
+	if len(cw.buf) > 0 {
+		if _, err := w.Write(cw.buf); err != nil {
+			return err
+		}
+		cw.buf = nil
+	}
+	if len(b) > 0 {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cw *compressWriter) compressible(b []byte) bool {
+	hd := cw.Header()
+	if hd.Get("Content-Encoding") != "" || cw.status == http.StatusNoContent ||
+		cw.status == http.StatusNotModified || cw.status == http.StatusPartialContent {
+		return false
+	}
+	ct := hd.Get("Content-Type")
+	if ct == "" {
+		//net/http would sniff it, and send it along
+		ct = http.DetectContentType(append(cw.buf, b...))
+		hd.Set("Content-Type", ct)
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	if mt == "image/svg+xml" {
+		return true
+	}
+	for _, st := range cw.opts.SkipTypes {
+		if mt == st || strings.HasSuffix(st, "/") && strings.HasPrefix(mt, st) {
+			return false
+		}
+	}
+	return true
+}
+
+// close sends the small bodies, and flushes the compressed ones.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			//nothing was written, let net/http send its default
+			return
+		}
+		cw.decide(false, nil)
+	}
+	if cw.comp != nil {
+		cw.comp.Close()
+		cw.enc.put(cw.comp)
+		cw.comp = nil
+	}
+}
+
+// Flush sends the buffered bytes, compressed if the body is big enough.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(len(cw.buf) >= cw.opts.MinSize, nil)
+	}
+	if f, ok := cw.comp.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the websocket like handlers take over the connection.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := cw.ResponseWriter.(http.Hijacker); ok && !cw.decided {
+		cw.decided = true
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("compress: the ResponseWriter does not support Hijack")
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
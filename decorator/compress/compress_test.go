@@ -0,0 +1,176 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/compress"
+	"github.com/dlmc/golight/ghttp"
+)
+
+var big = strings.Repeat(`{"sku":"AB1234","count":1},`, 100)
+
+//Http request handler that writes the body in the given content type
+func thBody(ct, body string) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		if ct != "" {
+			h.W.Header().Set("Content-Type", ct)
+		}
+		//write in chunks, as the encoders do
+		for i := 0; i < len(body); i += 100 {
+			end := i + 100
+			if end > len(body) {
+				end = len(body)
+			}
+			h.W.Write([]byte(body[i:end]))
+		}
+		return c
+	})
+}
+
+func serve(h ghttp.Handler, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept-Encoding", accept)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+	return w
+}
+
+func decode(t *testing.T, enc string, b []byte) string {
+	var r io.Reader = bytes.NewReader(b)
+	var err error
+	switch enc {
+	case "gzip":
+		r, err = gzip.NewReader(r)
+	case "deflate":
+		r, err = zlib.NewReader(r)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+//Test cases for the compression decorator
+func TestCompress(t *testing.T) {
+	testCases := []struct {
+		ct, body, accept, enc string
+	}{
+		{"application/json", big, "gzip, deflate", "gzip"},
+		{"application/json", big, "gzip;q=0.5, deflate", "deflate"},
+		{"application/json", big, "*", "gzip"},
+		{"application/json", big, "br", ""},
+		{"application/json", big, "gzip;q=0", ""},
+		{"application/json", big, "", ""},
+		{"application/json", `{"small":true}`, "gzip", ""},
+		{"image/png", big, "gzip", ""},
+		{"image/svg+xml", big, "gzip", "gzip"},
+		{"", big, "gzip", "gzip"},
+	}
+	for i, tc := range testCases {
+		//twice, to reuse the pooled compressors
+		for j := 0; j < 2; j++ {
+			w := serve(decorator.Decorate(thBody(tc.ct, tc.body), compress.CreateDecor()), tc.accept)
+			if got := w.Header().Get("Content-Encoding"); got != tc.enc {
+				t.Errorf("%d. Compress encoding failed, got: %q, want: %q", i, got, tc.enc)
+				continue
+			}
+			if got := decode(t, tc.enc, w.Body.Bytes()); got != tc.body {
+				t.Errorf("%d. Compress body failed, got: %q", i, got)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("%d. Compress Vary failed, got: %v", i, w.Header())
+			}
+			if tc.enc != "" && w.Body.Len() >= len(tc.body) {
+				t.Errorf("%d. Compress did not compress, got: %d bytes", i, w.Body.Len())
+			}
+		}
+	}
+}
+
+func TestCompressStatus(t *testing.T) {
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		h.W.Header().Set("Content-Type", "text/plain")
+		h.W.Header().Set("Content-Length", "2700")
+		h.W.WriteHeader(http.StatusCreated)
+		h.W.Write([]byte(big))
+		return c
+	}), compress.CreateDecor())
+	w := serve(h, "gzip")
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Length") != "" || decode(t, "gzip", w.Body.Bytes()) != big {
+		t.Errorf("Compress status failed, got: %d %v", w.Code, w.Header())
+	}
+
+	//already encoded
+	h = decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		h.W.Header().Set("Content-Encoding", "br")
+		h.W.Write([]byte(big))
+		return c
+	}), compress.CreateDecor())
+	if w := serve(h, "gzip"); w.Header().Get("Content-Encoding") != "br" || w.Body.String() != big {
+		t.Errorf("Compress encoded body failed, got: %v", w.Header())
+	}
+}
+
+func TestCompressEncoders(t *testing.T) {
+	//a custom encoder registered for one decorator
+	custom := compress.NewEncoder("x-gzip", func(w io.Writer) compress.Compressor {
+		return gzip.NewWriter(w)
+	})
+	h := decorator.Decorate(thBody("text/plain", big), compress.CreateDecorWith(compress.Options{
+		Encoders: []*compress.Encoder{custom, compress.Gzip}, MinSize: 10}))
+	w := serve(h, "gzip, x-gzip")
+	if w.Header().Get("Content-Encoding") != "x-gzip" || decode(t, "gzip", w.Body.Bytes()) != big {
+		t.Errorf("Compress custom encoder failed, got: %v", w.Header())
+	}
+
+	compress.Register(custom)
+	if encs := compress.Encoders(); len(encs) != 3 || encs[0] != custom || encs[1] != compress.Gzip {
+		t.Errorf("Register failed, got: %v", encs)
+	}
+}
+
+//Compressor standing for a zstd encoder, it writes the body as is
+type fakeZstd struct {
+	w      io.Writer
+	closed *int
+}
+
+func (f *fakeZstd) Write(p []byte) (int, error) { return f.w.Write(p) }
+func (f *fakeZstd) Close() error               { *f.closed++; return nil }
+func (f *fakeZstd) Reset(w io.Writer)          { f.w = w }
+
+//Test cases for zstd plugged in with Register
+func TestCompressZstdPlugin(t *testing.T) {
+	closed := 0
+	compress.Register(compress.NewEncoder("zstd", func(w io.Writer) compress.Compressor {
+		return &fakeZstd{w: w, closed: &closed}
+	}))
+	h := decorator.Decorate(thBody("text/plain", big), compress.CreateDecor())
+
+	w := serve(h, "gzip, deflate, zstd")
+	if w.Header().Get("Content-Encoding") != "zstd" || w.Body.String() != big || closed != 1 {
+		t.Errorf("Compress zstd plugin failed, got: %v %d", w.Header(), closed)
+	}
+	w = serve(h, "gzip;q=1, zstd;q=0.5")
+	if w.Header().Get("Content-Encoding") != "gzip" || decode(t, "gzip", w.Body.Bytes()) != big {
+		t.Errorf("Compress zstd q-value failed, got: %v", w.Header())
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compressor is a compressing writer that can be reused with Reset, e.g.
+// *gzip.Writer, *flate.Writer or *zstd.Encoder.
+type Compressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// Encoder is a content coding with its pool of Compressors.
+type Encoder struct {
+	name string
+	pool sync.Pool
+}
+
+// NewEncoder creates the Encoder of the content coding name, newFn
+// creates its Compressors. It plugs in the codings that are not built in.
+// Usage example:
+/*
+	//zstd with github.com/klauspost/compress/zstd, not a golight dependency
+	compress.Register(compress.NewEncoder("zstd", func(w io.Writer) compress.Compressor {
+		enc, _ := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		return enc
+	}))
+*/
+func NewEncoder(name string, newFn func(w io.Writer) Compressor) *Encoder {
+	e := &Encoder{name: strings.ToLower(name)}
+	e.pool.New = func() interface{} { return newFn(nil) }
+	return e
+}
+
+// Name returns the content coding name, e.g. gzip.
+func (e *Encoder) Name() string {
+	return e.name
+}
+
+func (e *Encoder) get(w io.Writer) Compressor {
+	c := e.pool.Get().(Compressor)
+	c.Reset(w)
+	return c
+}
+
+func (e *Encoder) put(c Compressor) {
+	c.Reset(nil)
+	e.pool.Put(c)
+}
+
+// NewGzip creates the gzip Encoder with the compression level.
+func NewGzip(level int) *Encoder {
+	return NewEncoder("gzip", func(w io.Writer) Compressor {
+		gw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			panic(err)
+		}
+		return gw
+	})
+}
+
+// NewDeflate creates the deflate Encoder with the compression level.
+// The deflate content coding is the zlib format, refer to RFC 9110.
+func NewDeflate(level int) *Encoder {
+	return NewEncoder("deflate", func(w io.Writer) Compressor {
+		zw, err := zlib.NewWriterLevel(w, level)
+		if err != nil {
+			panic(err)
+		}
+		return zw
+	})
+}
+
+// The built-in encoders with the default compression level
+var (
+	Gzip    = NewGzip(gzip.DefaultCompression)
+	Deflate = NewDeflate(flate.DefaultCompression)
+)
+
+var (
+	mu       sync.Mutex
+	encoders = []*Encoder{Gzip, Deflate}
+)
+
+// Register registers the Encoder, e.g. zstd, for the decorators created
+// afterwards. The registered encoders are preferred to gzip and deflate,
+// the last registered first. It replaces the encoder of the same name.
+func Register(e *Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	encs := []*Encoder{e}
+	for _, o := range encoders {
+		if o.name != e.name {
+			encs = append(encs, o)
+		}
+	}
+	encoders = encs
+}
+
+// Encoders returns the registered encoders, in preference order.
+func Encoders() []*Encoder {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]*Encoder(nil), encoders...)
+}
+
+// negotiate returns the encoder with the highest q-value in the
+// Accept-Encoding header, the preferred one for the ties. It returns nil
+// for identity.
+func negotiate(accept string, encs []*Encoder) *Encoder {
+	if accept == "" {
+		return nil
+	}
+	qs := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			} else {
+				q = 0
+			}
+		}
+		qs[name] = q
+	}
+
+	var best *Encoder
+	bestQ := 0.0
+	for _, e := range encs {
+		q, ok := qs[e.name]
+		if !ok {
+			q, ok = qs["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,253 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Config is the CORS policy, refer to https://fetch.spec.whatwg.org/#http-cors-protocol
+type Config struct {
+	// AllowedOrigins are the origins allowed to make requests, "*" allows
+	// any origin and "https://*.example.com" any subdomain of example.com.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in the preflight requests.
+	// The methods of the Router if empty, GET, HEAD and POST for the
+	// decorators created by CreateDecor.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in the preflight
+	// requests, "*" allows any header. Accept, Accept-Language,
+	// Content-Language, Content-Type and Authorization if empty.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers the client can read.
+	ExposedHeaders []string
+	// AllowCredentials allows the requests with cookies and credentials.
+	AllowCredentials bool
+	// MaxAge is how long the preflight responses can be cached.
+	MaxAge time.Duration
+}
+
+var defaultHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type", "Authorization"}
+
+// policy is the Config compiled for matching.
+type policy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   [][2]string // prefix and suffix of the wildcard origins
+	methods     []string
+	anyHeader   bool
+	headers     map[string]bool
+	headersList string
+	exposed     string
+	credentials bool
+	maxAge      string
+}
+
+func compile(cfg Config) *policy {
+	p := &policy{origins: map[string]bool{}, headers: map[string]bool{},
+		credentials: cfg.AllowCredentials}
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(o)
+		switch i := strings.IndexByte(o, '*'); {
+		case o == "*":
+			p.anyOrigin = true
+		case i >= 0:
+			p.wildcards = append(p.wildcards, [2]string{o[:i], o[i+1:]})
+		default:
+			p.origins[o] = true
+		}
+	}
+	for _, m := range cfg.AllowedMethods {
+		p.methods = append(p.methods, strings.ToUpper(m))
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+	for _, hd := range headers {
+		if hd == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(hd)] = true
+	}
+	p.headersList = strings.Join(headers, ", ")
+	p.exposed = strings.Join(cfg.ExposedHeaders, ", ")
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+	return p
+}
+
+func (p *policy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if p.origins[o] {
+		return true
+	}
+	for _, wc := range p.wildcards {
+		if len(o) > len(wc[0])+len(wc[1]) && strings.HasPrefix(o, wc[0]) && strings.HasSuffix(o, wc[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin sets the allowed origin headers, "*" is only sent to any
+// origin without credentials as the browsers reject it otherwise.
+func (p *policy) setOrigin(hd http.Header, origin string) {
+	if p.anyOrigin && !p.credentials {
+		hd.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		hd.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.credentials {
+		hd.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// preflight answers the preflight request with 204, without the CORS
+// headers when the request is not allowed, so the browser fails it.
+func (p *policy) preflight(w http.ResponseWriter, r *http.Request, methods []string) {
+	hd := w.Header()
+	hd.Add("Vary", "Origin")
+	hd.Add("Vary", "Access-Control-Request-Method")
+	hd.Add("Vary", "Access-Control-Request-Headers")
+	defer w.WriteHeader(http.StatusNoContent)
+
+	origin := r.Header.Get("Origin")
+	if !p.allowOrigin(origin) {
+		return
+	}
+	if len(p.methods) > 0 {
+		methods = p.methods
+	}
+	if !contains(methods, strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))) {
+		return
+	}
+	reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if !p.anyHeader {
+		for _, rh := range strings.Split(reqHeaders, ",") {
+			if rh = strings.TrimSpace(rh); rh != "" && !p.headers[http.CanonicalHeaderKey(rh)] {
+				return
+			}
+		}
+	}
+
+	p.setOrigin(hd, origin)
+	hd.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if p.anyHeader {
+		if reqHeaders != "" {
+			hd.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+	} else {
+		hd.Set("Access-Control-Allow-Headers", p.headersList)
+	}
+	if p.maxAge != "" {
+		hd.Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+// actual adds the CORS headers to the response of the actual request.
+func (p *policy) actual(w http.ResponseWriter, r *http.Request) {
+	hd := w.Header()
+	hd.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" || !p.allowOrigin(origin) {
+		return
+	}
+	p.setOrigin(hd, origin)
+	if p.exposed != "" {
+		hd.Set("Access-Control-Expose-Headers", p.exposed)
+	}
+}
+
+func contains(strs []string, s string) bool {
+	for _, e := range strs {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+var simpleMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CreateDecor creates a decorator that adds the CORS headers of the policy
+// to the responses, and answers the preflight requests when it decorates
+// an OPTIONS handler.
+// The Routers without an OPTIONS handler answer the OPTIONS requests
+// themselves, so the decorator, e.g. of a Group, never sees their
+// preflight requests. Use Router for them, also inside a Group:
+/*
+	corsCfg := cors.Config{AllowedOrigins: []string{"https://*.example.com"}}
+	api := mux.Group("/api", decorator.DecoratorChain{respond.CreateDecor()})
+	api.Handle("/orders", cors.Router(corsCfg, ghttp.Router{"GET": listOrders, "POST": addOrder}))
+*/
+func CreateDecor(cfg Config) decorator.Decorator {
+	return createDecor(compile(cfg), simpleMethods)
+}
+
+func createDecor(p *policy, methods []string) decorator.Decorator {
+	return decorator.Named("cors", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			if isPreflight(h.R) {
+				p.preflight(h.W, h.R, methods)
+				return c
+			}
+			p.actual(h.W, h.R)
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// Router returns a copy of the Router with its handlers decorated by the
+// CORS decorator, and an OPTIONS handler that answers the preflight
+// requests with the methods of the Router in Access-Control-Allow-Methods.
+// The other OPTIONS requests are passed to the OPTIONS handler of rt, or
+// answered with the Allow header as the Router does.
+// Usage example:
+/*
+	corsCfg := cors.Config{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	mux.Handle("/orders/{id}", cors.Router(corsCfg, ghttp.Router{"GET": getOrder, "PUT": putOrder}))
+*/
+func Router(cfg Config, rt ghttp.Router) ghttp.Router {
+	methods := rt.Methods()
+	allow := strings.Join(methods, ", ")
+	d := createDecor(compile(cfg), methods)
+
+	options := rt[http.MethodOptions]
+	if options == nil {
+		options = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.Header().Set("Allow", allow)
+			h.W.WriteHeader(http.StatusOK)
+			return c
+		})
+	}
+	crt := ghttp.Router{http.MethodOptions: d(options)}
+	for m, hdl := range rt {
+		if m != http.MethodOptions {
+			crt[m] = d(hdl)
+		}
+	}
+	return crt
+}
@@ -0,0 +1,133 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/cors"
+	"github.com/dlmc/golight/ghttp"
+)
+
+var thOK = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.W.Write([]byte("ok"))
+	return c
+})
+
+func newCorsMux(cfg cors.Config) *ghttp.Mux {
+	mux := ghttp.NewMux()
+	mux.Handle("/orders", cors.Router(cfg, ghttp.Router{"GET": thOK, "PUT": thOK}))
+	return mux
+}
+
+//Test cases for the preflight requests
+func TestPreflight(t *testing.T) {
+	cfg := cors.Config{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	testCases := []struct {
+		origin, method, headers string
+		allowOrigin, allowMethods string
+	}{
+		{"https://app.example.com", "PUT", "content-type", "https://app.example.com", "GET, PUT"},
+		{"https://a.b.example.org", "GET", "", "https://a.b.example.org", "GET, PUT"},
+		{"https://example.org", "GET", "", "", ""},
+		{"https://evil.com", "GET", "", "", ""},
+		{"https://app.example.com", "DELETE", "", "", ""},
+		{"https://app.example.com", "PUT", "X-Custom", "", ""},
+	}
+
+	mux := newCorsMux(cfg)
+	for i, tc := range testCases {
+		r := httptest.NewRequest("OPTIONS", "/orders", nil)
+		r.Header.Set("Origin", tc.origin)
+		r.Header.Set("Access-Control-Request-Method", tc.method)
+		if tc.headers != "" {
+			r.Header.Set("Access-Control-Request-Headers", tc.headers)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		hd := w.Header()
+		if w.Code != http.StatusNoContent || hd.Get("Access-Control-Allow-Origin") != tc.allowOrigin ||
+			hd.Get("Access-Control-Allow-Methods") != tc.allowMethods {
+			t.Errorf("%d. Preflight failed, got: %d %v", i, w.Code, hd)
+		}
+		if vary := strings.Join(hd["Vary"], ", "); vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+			t.Errorf("%d. Preflight Vary failed, got: %s", i, vary)
+		}
+		if tc.allowOrigin != "" && (hd.Get("Access-Control-Allow-Credentials") != "true" ||
+			hd.Get("Access-Control-Max-Age") != "600" || hd.Get("Access-Control-Allow-Headers") == "") {
+			t.Errorf("%d. Preflight headers failed, got: %v", i, hd)
+		}
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	mux := newCorsMux(cors.Config{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{"X-Total"}})
+
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r.Header.Set("Origin", "https://any.com")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	hd := w.Header()
+	if w.Body.String() != "ok" || hd.Get("Access-Control-Allow-Origin") != "*" ||
+		hd.Get("Access-Control-Expose-Headers") != "X-Total" || hd.Get("Vary") != "Origin" {
+		t.Errorf("Actual request failed, got: %s %v", w.Body.String(), hd)
+	}
+
+	//Plain OPTIONS is answered as the Router does
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/orders", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Allow") != "GET, PUT" {
+		t.Errorf("OPTIONS failed, got: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCreateDecor(t *testing.T) {
+	h := cors.CreateDecor(cors.Config{AllowedOrigins: []string{"https://app.example.com"}, AllowedHeaders: []string{"*"}})(thOK)
+	r := httptest.NewRequest("OPTIONS", "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	r.Header.Set("Access-Control-Request-Headers", "X-Anything")
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: r})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Headers") != "X-Anything" ||
+		w.Header().Get("Access-Control-Allow-Methods") != "GET, HEAD, POST" {
+		t.Errorf("CreateDecor preflight failed, got: %d %v", w.Code, w.Header())
+	}
+}
+
+//Test cases for the preflight requests of a Router in a Group
+func TestPreflightGroup(t *testing.T) {
+	mux := ghttp.NewMux()
+	group := decorator.Named("group", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.Header().Set("X-Group", "api")
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+	api := mux.Group("/api", decorator.DecoratorChain{group})
+	api.Handle("/orders", cors.Router(cors.Config{AllowedOrigins: []string{"https://app.example.com"}},
+		ghttp.Router{"GET": thOK, "POST": thOK}))
+
+	r := httptest.NewRequest("OPTIONS", "/api/orders", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	hd := w.Header()
+	if w.Code != http.StatusNoContent || hd.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		hd.Get("Access-Control-Allow-Methods") != "GET, POST" || hd.Get("X-Group") != "api" {
+		t.Errorf("Preflight in a Group failed, got: %d %v", w.Code, hd)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package decompress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Options configures CreateDecorWith.
+type Options struct {
+	// MaxSize is the max size of the body as sent, 1MB if 0, no limit if < 0.
+	MaxSize int64
+	// MaxDecompressedSize is the max size of the decompressed body, which
+	// protects from the zip bombs. 8 times MaxSize if 0, no limit if < 0.
+	MaxDecompressedSize int64
+}
+
+// CreateDecor creates a decorator that decompresses the request bodies and
+// limits their sizes to the defaults, refer to CreateDecorWith for details.
+func CreateDecor() decorator.Decorator {
+	return CreateDecorWith(Options{})
+}
+
+// CreateDecorWith creates a decorator that transparently decompresses the
+// gzip and deflate request bodies in h.R, and limits the size of the body
+// as sent and once decompressed.
+// The requests whose Content-Length exceeds MaxSize get a 413 right away.
+// Otherwise reading past a limit fails with an *http.MaxBytesError, which
+// Http.Bind reports as a 413 BindError. If the handler does not respond
+// after the limit was hit, the decorator sends the 413 ghttp.Response.
+// The other content codings get a 415.
+// Usage example:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(),
+		decompress.CreateDecorWith(decompress.Options{MaxSize: 512 << 10, MaxDecompressedSize: 4 << 20}))
+*/
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = 1 << 20
+	}
+	if opts.MaxDecompressedSize == 0 {
+		opts.MaxDecompressedSize = 8 * opts.MaxSize
+	}
+	return decorator.Named("decompress", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			r := h.R
+			if r.Body == nil || r.Body == http.NoBody {
+				return next.ServeHTTPWithCtx(c, h)
+			}
+			if opts.MaxSize > 0 && r.ContentLength > opts.MaxSize {
+				return fail(c, h, http.StatusRequestEntityTooLarge)
+			}
+
+			hit := new(atomic.Bool)
+			body := r.Body
+			if opts.MaxSize > 0 {
+				body = &limitReader{rc: body, n: opts.MaxSize, limit: opts.MaxSize, hit: hit}
+			}
+			switch enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); enc {
+			case "", "identity":
+			case "gzip", "x-gzip", "deflate":
+				dr, err := newDecompressor(enc, body)
+				if err != nil {
+					if hit.Load() {
+						return fail(c, h, http.StatusRequestEntityTooLarge)
+					}
+					return fail(c, h, http.StatusBadRequest)
+				}
+				body = dr
+				if opts.MaxDecompressedSize > 0 {
+					body = &limitReader{rc: body, n: opts.MaxDecompressedSize, limit: opts.MaxDecompressedSize, hit: hit}
+				}
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			default:
+				return fail(c, h, http.StatusUnsupportedMediaType)
+			}
+			r.Body = body
+
+			w := ghttp.WrapWriter(h.W)
+			orig := h.W
+			h.W = w
+			c = next.ServeHTTPWithCtx(c, h)
+			h.W = orig
+			if hit.Load() && !w.Written() {
+				return fail(c, h, http.StatusRequestEntityTooLarge)
+			}
+			return c
+		})
+	})
+}
+
+// fail sends the ghttp.Response of the status as JSON.
+func fail(c ghttp.Ctx, h *ghttp.Http, status int) ghttp.Ctx {
+	h.Resp = ghttp.Response{Code: status, Message: http.StatusText(status)}
+	if status == http.StatusUnsupportedMediaType {
+		h.W.Header().Set("Accept-Encoding", "gzip, deflate")
+	}
+	h.W.Header().Set("Content-Type", "application/json; charset=utf-8")
+	h.W.WriteHeader(status)
+	json.NewEncoder(h.W).Encode(h.Resp)
+	return c
+}
+
+// limitReader fails with an *http.MaxBytesError past the limit.
+type limitReader struct {
+	rc    io.ReadCloser
+	n     int64 // the bytes left
+	limit int64
+	hit   *atomic.Bool
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	if lr.n < 0 {
+		return 0, &http.MaxBytesError{Limit: lr.limit}
+	}
+	//read one more byte to tell a body of exactly the limit from a bigger one
+	if int64(len(p)) > lr.n+1 {
+		p = p[:lr.n+1]
+	}
+	n, err := lr.rc.Read(p)
+	if int64(n) <= lr.n {
+		lr.n -= int64(n)
+		return n, err
+	}
+	n = int(lr.n)
+	lr.n = -1
+	lr.hit.Store(true)
+	return n, &http.MaxBytesError{Limit: lr.limit}
+}
+
+func (lr *limitReader) Close() error {
+	return lr.rc.Close()
+}
+
+var gzipPool sync.Pool
+
+// decompressor closes the request body with the decompressing reader,
+// and puts the gzip readers back into the pool.
+type decompressor struct {
+	io.Reader
+	body io.Closer
+	gz   *gzip.Reader
+}
+
+func newDecompressor(enc string, body io.ReadCloser) (*decompressor, error) {
+	if enc == "deflate" {
+		zr, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return &decompressor{Reader: zr, body: body}, nil
+	}
+
+	gz, _ := gzipPool.Get().(*gzip.Reader)
+	var err error
+	if gz == nil {
+		gz, err = gzip.NewReader(body)
+	} else {
+		err = gz.Reset(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &decompressor{Reader: gz, body: body, gz: gz}, nil
+}
+
+func (d *decompressor) Close() error {
+	if d.gz != nil {
+		d.gz.Close()
+		gzipPool.Put(d.gz)
+		d.gz = nil
+		d.Reader = eofReader{}
+	}
+	return d.body.Close()
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
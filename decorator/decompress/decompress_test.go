@@ -0,0 +1,116 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package decompress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/decompress"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+func gzipped(s string) []byte {
+	b := &bytes.Buffer{}
+	zw := gzip.NewWriter(b)
+	zw.Write([]byte(s))
+	zw.Close()
+	return b.Bytes()
+}
+
+func deflated(s string) []byte {
+	b := &bytes.Buffer{}
+	zw := zlib.NewWriter(b)
+	zw.Write([]byte(s))
+	zw.Close()
+	return b.Bytes()
+}
+
+//Http request handler that binds the body
+var thBind = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := h.BindWith(&req, ghttp.BindOptions{MaxBodySize: -1}); err != nil {
+		h.Err = err
+		return c
+	}
+	h.Resp.Message = req.Name
+	return c
+})
+
+//Http request handler that ignores the body errors
+var thIgnore = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	io.Copy(io.Discard, h.R.Body)
+	return c
+})
+
+func serve(h ghttp.Handler, enc string, body []byte) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if enc != "" {
+		r.Header.Set("Content-Encoding", enc)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+	return w
+}
+
+//Test cases for the decompression decorator
+func TestDecompress(t *testing.T) {
+	opts := decompress.Options{MaxSize: 200, MaxDecompressedSize: 1000}
+	bomb := `{"name":"` + strings.Repeat("a", 5000) + `"}`
+	testCases := []struct {
+		hdl  ghttp.Handler
+		enc  string
+		body []byte
+		code int
+		resp string
+	}{
+		{thBind, "gzip", gzipped(`{"name":"gz"}`), 200, `{"code":200,"message":"gz"}`},
+		{thBind, "deflate", deflated(`{"name":"fl"}`), 200, `{"code":200,"message":"fl"}`},
+		{thBind, "", []byte(`{"name":"plain"}`), 200, `{"code":200,"message":"plain"}`},
+		{thBind, "gzip", gzipped(bomb), 413, `{"code":413,"message":"bind: http: request body too large"}`},
+		{thBind, "", []byte(bomb), 413, `{"code":413,"message":"Request Entity Too Large"}`},
+		{thBind, "br", []byte("x"), 415, `{"code":415,"message":"Unsupported Media Type"}`},
+		{thBind, "gzip", []byte("not gzip"), 400, `{"code":400,"message":"Bad Request"}`},
+	}
+	for i, tc := range testCases {
+		h := decorator.Decorate(tc.hdl, respond.CreateDecor(), decompress.CreateDecorWith(opts))
+		w := serve(h, tc.enc, tc.body)
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.resp {
+			t.Errorf("%d. Decompress failed, got: %d %s, want: %d %s", i, w.Code, w.Body.String(), tc.code, tc.resp)
+		}
+	}
+}
+
+func TestDecompressIgnored(t *testing.T) {
+	bomb := strings.Repeat("a", 5000)
+	h := decorator.Decorate(thIgnore, decompress.CreateDecorWith(decompress.Options{MaxDecompressedSize: 1000}))
+	w := serve(h, "gzip", gzipped(bomb))
+	if want := `{"code":413,"message":"Request Entity Too Large"}`; w.Code != 413 || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("Decompress ignored failed, got: %d %s, want: 413 %s", w.Code, w.Body.String(), want)
+	}
+}
+
+func TestDecompressLimitExact(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", 20) + `"}`
+	for _, tc := range []struct {
+		max  int64
+		code int
+	}{{int64(len(body)), 200}, {int64(len(body)) - 1, 413}} {
+		h := decorator.Decorate(thBind, respond.CreateDecor(), decompress.CreateDecorWith(decompress.Options{MaxSize: -1, MaxDecompressedSize: tc.max}))
+		if w := serve(h, "gzip", gzipped(body)); w.Code != tc.code {
+			t.Errorf("Decompress limit %d failed, got: %d %s", tc.max, w.Code, w.Body.String())
+		}
+	}
+}
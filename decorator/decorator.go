@@ -5,6 +5,9 @@
 package decorator
 
 import (
+	"reflect"
+	"runtime"
+
 	"github.com/dlmc/golight/ghttp"
 )
 
@@ -96,8 +99,14 @@ type Decorator func(ghttp.Handler) ghttp.Handler
 	after d1
 */ 
 // Refer to decorator_test for addtional info.
+// The unnamed decorators are listed by ghttp.Mux.Routes under the name of
+// their func, refer to Name.
 func Decorate(h ghttp.Handler, decorators ...Decorator) ghttp.Handler {
 	for _, d := range decorators {
+		if !isNamed(d) {
+			//so that Mux.Routes can walk past it to the inner decorators
+			d = Named(Name(d), d)
+		}
 		h = d(h)
 	}
 	return h
@@ -113,8 +122,11 @@ func DecorateRouter(r ghttp.Router, decorators ...Decorator) ghttp.Router {
 }
 
 // DecoratorChain is an array of Decorators.
+// It may be passed to ghttp.Mux.Group to decorate a group of routes.
 type DecoratorChain []Decorator
 
+var _ ghttp.Decorating = DecoratorChain(nil)
+
 // Chain chains the Decorators into an array.
 // DecorateChain may be re-used to decorate different http.Handler.
 func Chain(decorators ...Decorator) DecoratorChain {
@@ -133,8 +145,85 @@ func (dc DecoratorChain) DecorateRouter(r ghttp.Router) ghttp.Router {
 	return DecorateRouter(r, dc...)
 }
 
+// Names returns the names of the Decorators in the order they are called,
+// i.e. from the last to the first one. Refer to Name for details.
+func (dc DecoratorChain) Names() []string {
+	names := make([]string, 0, len(dc))
+	for i := len(dc) - 1; i >= 0; i-- {
+		names = append(names, Name(dc[i]))
+	}
+	return names
+}
+
 // Append additional Decorators to the given DecoratorChain.
 func (dc DecoratorChain) Append(decorators ...Decorator) DecoratorChain {
 		dc = append(dc, decorators...)
 		return dc
 }
+
+
+// namedHandler is the ghttp.NamedHandler created by a named Decorator.
+type namedHandler struct {
+	name string
+	h    ghttp.Handler // the decorated handler
+	next ghttp.Handler // the handler before decoration
+}
+
+func (nh *namedHandler) ServeHTTPWithCtx(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	return nh.h.ServeHTTPWithCtx(c, h)
+}
+
+func (nh *namedHandler) DecoratorName() string { return nh.name }
+func (nh *namedHandler) Unwrap() ghttp.Handler { return nh.next }
+
+// Named gives the Decorator a name so that it can be listed by the route
+// introspection of ghttp.Mux.Routes.
+// The decorators of golight are all named, e.g. "respond", "logging".
+// Usage example:
+/*
+	func CreateDecor(args ...interface{}) decorator.Decorator {
+		return decorator.Named("example", func(next ghttp.Handler) ghttp.Handler {
+			...
+		})
+	}
+*/
+func Named(name string, d Decorator) Decorator {
+	return func(next ghttp.Handler) ghttp.Handler {
+		if next == ghttp.Handler(probe) {
+			//Name asks for the name, d is not run
+			return &namedHandler{name: name}
+		}
+		return &namedHandler{name: name, h: d(next), next: next}
+	}
+}
+
+// probeHandler is the type of the handler Name passes to the decorators
+// returned by Named to get their name.
+type probeHandler struct{}
+
+func (*probeHandler) ServeHTTPWithCtx(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx { return c }
+
+var probe = &probeHandler{}
+
+// namedPC is the code pointer shared by the decorators returned by Named.
+var namedPC = reflect.ValueOf(Named("", nil)).Pointer()
+
+func isNamed(d Decorator) bool {
+	return d != nil && reflect.ValueOf(d).Pointer() == namedPC
+}
+
+// Name returns the name given to the Decorator by Named. The func name of
+// the Decorator, e.g. "main.authDecor.func1", is returned for the unnamed
+// ones. The Decorator is not run, so Name has no side effect.
+func Name(d Decorator) string {
+	if d == nil {
+		return ""
+	}
+	if isNamed(d) {
+		return d(probe).(*namedHandler).name
+	}
+	if f := runtime.FuncForPC(reflect.ValueOf(d).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
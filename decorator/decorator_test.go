@@ -262,3 +262,38 @@ func ExampleHttpServer() {
 
 }
 
+
+//Test cases for the Decorator names
+func TestDecoratorNames(t *testing.T) {
+	d1 := decorator.Named("d1", tDecorator("d1\n"))
+	d2 := tDecorator("d2\n")
+
+	if got := decorator.Name(d1); got != "d1" {
+		t.Errorf("Name failed, got: %s, want: d1", got)
+	}
+	if got, want := decorator.Name(d2), "github.com/dlmc/golight/decorator_test.tDecorator.func1"; got != want {
+		t.Errorf("Name failed, got: %s, want: %s", got, want)
+	}
+
+	names := decorator.Chain(d2, d1).Names()
+	if len(names) != 2 || names[0] != "d1" || names[1] != decorator.Name(d2) {
+		t.Errorf("Names failed, got: %v", names)
+	}
+
+	//Name does not run the decorator
+	calls := 0
+	d4 := decorator.Named("d4", func(next ghttp.Handler) ghttp.Handler {
+		calls++
+		return next
+	})
+	decorator.Name(d4)
+	decorator.Chain(d4, d2).Names()
+	if calls != 0 {
+		t.Errorf("Name ran the decorator %d times", calls)
+	}
+
+	//Named decorators behave the same as the unnamed ones
+	h := decorator.Decorate(th, decorator.Named("d3", tDecorator("d3\n")), d2, d1)
+	strRes := "before d1\nbefore d2\nbefore d3\nHandlerFunc\nafter d3\nafter d2\nafter d1\n"
+	tResult(t, h, strRes)
+}
@@ -19,7 +19,7 @@ type HeaderMap map[string]string
 //	    false to set the associated key with the value. It replaces any existing
 //            values associated with key.
 func CreateDecor(hm HeaderMap, add bool) decorator.Decorator {
-	return func(next ghttp.Handler) ghttp.Handler {
+	return decorator.Named("header", func(next ghttp.Handler) ghttp.Handler {
 		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx{
 			w := h.W
 			if add {
@@ -33,5 +33,5 @@ func CreateDecor(hm HeaderMap, add bool) decorator.Decorator {
 			}
 			return next.ServeHTTPWithCtx(c, h)
 		})
-	}		
+	})		
 }
@@ -7,81 +7,88 @@ package logging
 import (
 	"github.com/dlmc/golight/decorator"
 	"github.com/dlmc/golight/ghttp"
-	log "github.com/rs/zerolog"
+	"github.com/dlmc/golight/logger"
 	"io"
-	"time"
 )
 
 // LogObj or the composite of it that can be passed to Log.Print(), 
 // and e.Interface() functions.
-type LogObj map[string]interface{}
+type LogObj = logger.LogObj
 
-type LogLevel uint8
+type LogLevel = logger.Level
 
 const (
-	LogDebug = iota				// LogDebug defines debug log level.
-	LogInfo						// LogInfo defines info log level.
-	LogWarn						// LogWarn defines warn log level.
-	LogError						// LogError defines error log level.
-	LogFatal						// LogFatal defines fatal log level.
-	LogPanic						// LogPanic defines panic log level.
-	LogDisabled					// LogDisabled disables the logger.
+	LogDebug = logger.LogDebug			// LogDebug defines debug log level.
+	LogInfo = logger.LogInfo			// LogInfo defines info log level.
+	LogWarn = logger.LogWarn			// LogWarn defines warn log level.
+	LogError = logger.LogError			// LogError defines error log level.
+	LogFatal = logger.LogFatal			// LogFatal defines fatal log level.
+	LogPanic = logger.LogPanic			// LogPanic defines panic log level.
+	LogDisabled = logger.LogDisabled	// LogDisabled disables the logger.
 )
 
-func init() {
-	//zlog.TimeFieldFormat
-	log.TimestampFieldName = "t"
-	log.LevelFieldName = "l"
-	log.MessageFieldName = "m"
-	log.ErrorFieldName = "e"
-	log.DurationFieldInteger = true
-	log.DurationFieldUnit = time.Millisecond
-}
-
-func NewLogger(w io.Writer) log.Logger {
-	return log.New(w)
+func NewLogger(w io.Writer) logger.Logger {
+	return logger.New(w, false)
 }
 
 
-func NewContext(w io.Writer) log.Context {
-	return log.New(w).With()
+func NewContext(w io.Writer) logger.Context {
+	return logger.New(w, false).With()
 }
 
-func NewContextWithTimestamp(w io.Writer) log.Context {
-	return log.New(w).With().Timestamp()
+func NewContextWithTimestamp(w io.Writer) logger.Context {
+	return logger.New(w, true).With()
 }
 
-func NewLoggerWithTimestamp(w io.Writer) log.Logger {
-	return log.New(w).With().Timestamp().Logger()
+func NewLoggerWithTimestamp(w io.Writer) logger.Logger {
+	return logger.New(w, true)
 }
 
 
 // SetGlobalLevel sets the global log level.
 // Refer to logger_test for details of each usecases.
 func SetGlobalLevel(level LogLevel) {
-	log.SetGlobalLevel(log.Level(level))
+	logger.SetGlobalLevel(level)
 }
 
 
-// Internal int key
-//var loggingKey = ghttp.GetNextCtxKey()
+// Internal typed key
+var loggingKey = ghttp.NewKey[logger.Context]("logging")
 
 
-// GetLogger returns the Logger in the request Context
+// GetLogger returns the Logger in the request Context and whether it was found.
 // Prior to call GetLogger, the request will have to be decorated 
-// by the decor created by logger.CreateDecor
-//func GetLogger(c ghttp.Ctx) log.Context {
-//	return c.Value(loggingKey).(log.Context)
-//}
+// by the decor created by logging.CreateDecor
+func GetLogger(c ghttp.Ctx) (logger.Context, bool) {
+	return loggingKey.From(c)
+}
 
 // CreateDecor creates a decorator that adds the passed in Logger into the request context map
 // for future use
-func CreateDecor(lc log.Context) decorator.Decorator {
-	return func(next ghttp.Handler) ghttp.Handler {
+func CreateDecor(lc logger.Context) decorator.Decorator {
+	return decorator.Named("logging", func(next ghttp.Handler) ghttp.Handler {
 		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx{
-			//c = ghttp.ChildCtx(c, loggingKey, lc)
+			c = loggingKey.With(c, lc)
 			h.Log = lc
 			return next.ServeHTTPWithCtx(c, h)
 		})
-	}		
+	})		
+}
+
+// WithStr adds the string field to the logger of the request, in the Ctx
+// and in h.Log, so that the following log lines of the request carry it.
+// It returns c as is when the request is not decorated by the decor
+// created by logging.CreateDecor.
+// Usage example:
+/*
+	c = logging.WithStr(c, h, "user", user.ID)
+*/
+func WithStr(c ghttp.Ctx, h *ghttp.Http, key, val string) ghttp.Ctx {
+	lc, ok := GetLogger(c)
+	if !ok {
+		return c
+	}
+	lc = lc.Str(key, val)
+	h.Log = lc
+	return loggingKey.With(c, lc)
 }
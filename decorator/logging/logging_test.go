@@ -72,3 +72,26 @@ func TestLoggingDecorator(t *testing.T) {
 }
 
 
+
+func TestGetLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	lg := logging.CreateDecor(logging.NewContext(out).Str("k", "v"))
+
+	if _, ok := logging.GetLogger(nil); ok {
+		t.Errorf("GetLogger without logging decorator failed")
+	}
+
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		lc, ok := logging.GetLogger(c)
+		if !ok {
+			t.Fatalf("GetLogger failed")
+		}
+		lc.Logger().Info().Msg("s1")
+		return c
+	}), lg)
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{})
+
+	if got, want := out.String(), `{"l":"info","k":"v","m":"s1"}`+"\n"; got != want {
+		t.Errorf("GetLogger failed got:  %v\nwant: %v", got, want)
+	}
+}
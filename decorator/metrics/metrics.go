@@ -0,0 +1,111 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics records the rate, the errors and the duration of the
+// requests per route, and exposes them in the Prometheus text format
+// without a client library.
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// CreateDecor creates a decorator that records the requests in the
+// DefaultRegistry, refer to CreateDecorWith for details.
+func CreateDecor() decorator.Decorator {
+	return CreateDecorWith(DefaultRegistry)
+}
+
+// CreateDecorWith creates a decorator that records the requests in reg:
+//	namespace_http_requests_total             counter
+//	namespace_http_request_duration_seconds   histogram
+//	namespace_http_requests_in_flight         gauge
+// The counter and the histogram are labeled by the method, the route
+// pattern matched by the Mux, e.g. /users/{id}, and the status class, e.g.
+// 2xx. The raw path is never a label, so the number of series stays
+// bounded: the requests served without a pattern get the route "other",
+// and the unknown methods get the method "OTHER".
+// A panic of the handler is recorded as a 5xx and raised again, so the
+// decorator may be inner or outer to recovery.CreateDecor.
+// Usage example:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), metrics.CreateDecor(),
+		recovery.CreateDecor(), logging.CreateDecor(lc))
+
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET": h})
+	mux.Handle("/metrics", ghttp.Router{"GET": metrics.Handler(metrics.DefaultRegistry)})
+*/
+func CreateDecorWith(reg *Registry) decorator.Decorator {
+	return decorator.Named("metrics", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			start := time.Now()
+			atomic.AddInt64(&reg.inFlight, 1)
+			w := ghttp.WrapWriter(h.W)
+			orig := h.W
+			h.W = w
+			defer func() {
+				h.W = orig
+				atomic.AddInt64(&reg.inFlight, -1)
+				status := w.Status()
+				v := recover()
+				switch {
+				case v != nil:
+					status = http.StatusInternalServerError
+				case status == 0:
+					//net/http sends 200 for the handlers that write nothing
+					status = http.StatusOK
+				}
+				reg.observe(labels{method(h.R.Method), route(h.Pattern), statusClass(status)},
+					time.Since(start).Seconds())
+				if v != nil {
+					panic(v)
+				}
+			}()
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// Handler returns the handler that sends the metrics of reg in the
+// Prometheus text exposition format, e.g. for /metrics.
+func Handler(reg *Registry) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		b := reg.AppendText(make([]byte, 0, 4096))
+		h.W.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		h.W.WriteHeader(http.StatusOK)
+		h.W.Write(b)
+		return c
+	})
+}
+
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
+
+func route(pattern string) string {
+	if pattern == "" {
+		return "other"
+	}
+	return pattern
+}
+
+var statusClasses = [...]string{"1xx", "2xx", "3xx", "4xx", "5xx"}
+
+func statusClass(status int) string {
+	if i := status/100 - 1; i >= 0 && i < len(statusClasses) {
+		return statusClasses[i]
+	}
+	return "other"
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/metrics"
+	"github.com/dlmc/golight/ghttp"
+)
+
+func statusHandler(code int) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		if code != 0 {
+			h.W.WriteHeader(code)
+		}
+		return c
+	})
+}
+
+//Test cases for the request metrics
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry("test", []float64{0.5, 1})
+	decor := metrics.CreateDecorWith(reg)
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET": decorator.Decorate(statusHandler(0), decor),
+		"DELETE": decorator.Decorate(statusHandler(404), decor), "PURGE": decorator.Decorate(statusHandler(503), decor)})
+	mux.Handle("/metrics", ghttp.Router{"GET": metrics.Handler(reg)})
+
+	testCases := []struct {
+		method, path string
+	}{
+		{"GET", "/users/1"},
+		{"GET", "/users/2"},
+		{"DELETE", "/users/3"},
+		{"PURGE", "/users/4"},
+	}
+	for _, tc := range testCases {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+	}
+	if reg.Count("GET", "/users/{id}", "2xx") != 2 || reg.Count("DELETE", "/users/{id}", "4xx") != 1 ||
+		reg.Count("OTHER", "/users/{id}", "5xx") != 1 || reg.InFlight() != 0 {
+		t.Errorf("Count failed")
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	if w.Header().Get("Content-Type") != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type failed, got: %s", w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"# TYPE test_http_requests_total counter\n",
+		`test_http_requests_total{method="GET",route="/users/{id}",status="2xx"} 2` + "\n",
+		`test_http_requests_total{method="DELETE",route="/users/{id}",status="4xx"} 1` + "\n",
+		`test_http_requests_total{method="OTHER",route="/users/{id}",status="5xx"} 1` + "\n",
+		"# TYPE test_http_request_duration_seconds histogram\n",
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="2xx",le="0.5"} 2` + "\n",
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="2xx",le="1"} 2` + "\n",
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="2xx",le="+Inf"} 2` + "\n",
+		`test_http_request_duration_seconds_sum{method="GET",route="/users/{id}",status="2xx"} `,
+		`test_http_request_duration_seconds_count{method="GET",route="/users/{id}",status="2xx"} 2` + "\n",
+		"test_http_requests_in_flight 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics missing %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "/users/1") {
+		t.Errorf("Metrics leaked the raw path, got:\n%s", body)
+	}
+}
+
+func TestMetricsPanic(t *testing.T) {
+	reg := metrics.NewRegistry("", nil)
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		panic("boom")
+	}), metrics.CreateDecorWith(reg))
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("Panic not raised again, got: %v", v)
+			}
+		}()
+		ghttp.Router{"POST": h}.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+	}()
+	if reg.Count(http.MethodPost, "other", "5xx") != 1 || reg.InFlight() != 0 {
+		t.Errorf("Panic not recorded, got: %s", reg.AppendText(nil))
+	}
+	if !strings.Contains(string(reg.AppendText(nil)), `http_requests_total{method="POST",route="other",status="5xx"} 1`) {
+		t.Errorf("Namespace failed, got: %s", reg.AppendText(nil))
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the request duration
+// histogram buckets. They are the buckets of the Prometheus client.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics of the requests. It is safe for concurrent use.
+type Registry struct {
+	namespace string
+	buckets   []float64
+	inFlight  int64
+
+	mu     sync.RWMutex
+	series map[labels]*series
+}
+
+// labels are the labels of a series, bounded by the routes of the Mux.
+type labels struct {
+	method, route, status string
+}
+
+// series are the request counter and the duration histogram of labels.
+type series struct {
+	count   uint64
+	sum     uint64   // float64 bits of the sum of the durations
+	buckets []uint64 // the non cumulative counts of the buckets
+}
+
+// DefaultRegistry is the Registry of CreateDecor.
+var DefaultRegistry = NewRegistry("golight", nil)
+
+// NewRegistry creates a Registry whose metric names start with namespace_,
+// with the duration histogram buckets in seconds, DefaultBuckets if nil.
+// It panics when the buckets are not sorted.
+func NewRegistry(namespace string, buckets []float64) *Registry {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: the buckets are not sorted")
+	}
+	if namespace != "" {
+		namespace += "_"
+	}
+	return &Registry{namespace: namespace, buckets: buckets, series: make(map[labels]*series)}
+}
+
+// observe records a request of labels that lasted seconds.
+func (reg *Registry) observe(l labels, seconds float64) {
+	reg.mu.RLock()
+	s := reg.series[l]
+	reg.mu.RUnlock()
+	if s == nil {
+		reg.mu.Lock()
+		if s = reg.series[l]; s == nil {
+			s = &series{buckets: make([]uint64, len(reg.buckets))}
+			reg.series[l] = s
+		}
+		reg.mu.Unlock()
+	}
+
+	if i := sort.SearchFloat64s(reg.buckets, seconds); i < len(s.buckets) {
+		atomic.AddUint64(&s.buckets[i], 1)
+	}
+	for {
+		old := atomic.LoadUint64(&s.sum)
+		sum := math.Float64bits(math.Float64frombits(old) + seconds)
+		if atomic.CompareAndSwapUint64(&s.sum, old, sum) {
+			break
+		}
+	}
+	atomic.AddUint64(&s.count, 1)
+}
+
+// InFlight returns the number of requests being served.
+func (reg *Registry) InFlight() int64 {
+	return atomic.LoadInt64(&reg.inFlight)
+}
+
+// Count returns the number of requests of the method, route pattern and
+// status class, e.g. "GET", "/users/{id}", "2xx".
+func (reg *Registry) Count(method, route, status string) uint64 {
+	reg.mu.RLock()
+	s := reg.series[labels{method, route, status}]
+	reg.mu.RUnlock()
+	if s == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&s.count)
+}
+
+// AppendText appends the metrics in the Prometheus text exposition format,
+// refer to https://prometheus.io/docs/instrumenting/exposition_formats/
+func (reg *Registry) AppendText(b []byte) []byte {
+	reg.mu.RLock()
+	keys := make([]labels, 0, len(reg.series))
+	for l := range reg.series {
+		keys = append(keys, l)
+	}
+	reg.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		a, o := keys[i], keys[j]
+		if a.route != o.route {
+			return a.route < o.route
+		}
+		if a.method != o.method {
+			return a.method < o.method
+		}
+		return a.status < o.status
+	})
+
+	name := reg.namespace + "http_requests_total"
+	b = appendHeader(b, name, "counter", "The number of HTTP requests served.")
+	for _, l := range keys {
+		s := reg.get(l)
+		b = appendSample(b, name, l, "", atomic.LoadUint64(&s.count))
+	}
+
+	name = reg.namespace + "http_request_duration_seconds"
+	b = appendHeader(b, name, "histogram", "The duration of the HTTP requests in seconds.")
+	for _, l := range keys {
+		s := reg.get(l)
+		//read the count first, so that the +Inf bucket is not lower than the others
+		count := atomic.LoadUint64(&s.count)
+		var cum uint64
+		for i, le := range reg.buckets {
+			cum += atomic.LoadUint64(&s.buckets[i])
+			if cum > count {
+				cum = count
+			}
+			b = appendSample(b, name+"_bucket", l, strconv.FormatFloat(le, 'g', -1, 64), cum)
+		}
+		b = appendSample(b, name+"_bucket", l, "+Inf", count)
+		b = append(b, name+"_sum"...)
+		b = appendLabels(b, l, "")
+		b = append(b, ' ')
+		b = strconv.AppendFloat(b, math.Float64frombits(atomic.LoadUint64(&s.sum)), 'g', -1, 64)
+		b = append(b, '\n')
+		b = appendSample(b, name+"_count", l, "", count)
+	}
+
+	name = reg.namespace + "http_requests_in_flight"
+	b = appendHeader(b, name, "gauge", "The number of HTTP requests being served.")
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, reg.InFlight(), 10)
+	return append(b, '\n')
+}
+
+func (reg *Registry) get(l labels) *series {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.series[l]
+}
+
+func appendHeader(b []byte, name, typ, help string) []byte {
+	b = append(b, "# HELP "...)
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = append(b, help...)
+	b = append(b, "\n# TYPE "...)
+	b = append(b, name...)
+	b = append(b, ' ')
+	b = append(b, typ...)
+	return append(b, '\n')
+}
+
+func appendSample(b []byte, name string, l labels, le string, v uint64) []byte {
+	b = append(b, name...)
+	b = appendLabels(b, l, le)
+	b = append(b, ' ')
+	b = strconv.AppendUint(b, v, 10)
+	return append(b, '\n')
+}
+
+func appendLabels(b []byte, l labels, le string) []byte {
+	b = appendLabel(append(b, '{'), "method", l.method)
+	b = appendLabel(append(b, ','), "route", l.route)
+	b = appendLabel(append(b, ','), "status", l.status)
+	if le != "" {
+		b = appendLabel(append(b, ','), "le", le)
+	}
+	return append(b, '}')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func appendLabel(b []byte, name, val string) []byte {
+	b = append(b, name...)
+	b = append(b, `="`...)
+	b = append(b, labelEscaper.Replace(val)...)
+	return append(b, '"')
+}
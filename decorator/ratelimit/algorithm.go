@@ -0,0 +1,155 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// Result is the outcome of taking one request from the limit of a key.
+type Result struct {
+	Allowed    bool
+	Limit      int           // the max number of requests
+	Remaining  int           // the requests left
+	Reset      time.Duration // until the limit is fully available again
+	RetryAfter time.Duration // until the next request is allowed, when rejected
+}
+
+// Algorithm computes the rate limit of a key from its state.
+// The state is encoded into bytes, so that any Store can keep it.
+type Algorithm interface {
+	// Take takes one request. state is nil for a new key, it returns the
+	// next state to store and the result.
+	Take(state []byte, now time.Time) (next []byte, r Result)
+	// TTL returns how long the state is needed after the last request.
+	TTL() time.Duration
+}
+
+// TokenBucket returns the token bucket Algorithm: the bucket holds up to
+// burst tokens and is refilled with limit tokens per period, a request
+// takes a token.
+// Usage example:
+/*
+	//10 requests per second with bursts of up to 20 requests
+	alg := ratelimit.TokenBucket(10, time.Second, 20)
+*/
+func TokenBucket(limit int, per time.Duration, burst int) Algorithm {
+	if limit <= 0 || per <= 0 || burst <= 0 {
+		panic("ratelimit: invalid token bucket")
+	}
+	return &tokenBucket{rate: float64(limit) / float64(per), burst: float64(burst)}
+}
+
+type tokenBucket struct {
+	rate  float64 // tokens per nanosecond
+	burst float64
+}
+
+// The state is made of the tokens and the time of the last refill.
+func (tb *tokenBucket) Take(state []byte, now time.Time) ([]byte, Result) {
+	tokens, last := tb.burst, now.UnixNano()
+	if len(state) == 16 {
+		tokens = math.Float64frombits(binary.BigEndian.Uint64(state))
+		last = int64(binary.BigEndian.Uint64(state[8:]))
+	}
+	if elapsed := now.UnixNano() - last; elapsed > 0 {
+		tokens = math.Min(tb.burst, tokens+float64(elapsed)*tb.rate)
+	}
+
+	r := Result{Limit: int(tb.burst)}
+	if tokens >= 1 {
+		tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = time.Duration(math.Ceil((1 - tokens) / tb.rate))
+	}
+	r.Remaining = int(tokens)
+	r.Reset = time.Duration(math.Ceil((tb.burst - tokens) / tb.rate))
+
+	next := make([]byte, 16)
+	binary.BigEndian.PutUint64(next, math.Float64bits(tokens))
+	binary.BigEndian.PutUint64(next[8:], uint64(now.UnixNano()))
+	return next, r
+}
+
+func (tb *tokenBucket) TTL() time.Duration {
+	return time.Duration(math.Ceil(tb.burst / tb.rate))
+}
+
+// SlidingWindow returns the sliding window Algorithm: at most limit
+// requests are allowed in any window. The count of the sliding window is
+// estimated from the counts of the current and the previous fixed windows,
+// weighting the previous one by its overlap with the sliding window.
+// Usage example:
+/*
+	//100 requests per minute
+	alg := ratelimit.SlidingWindow(100, time.Minute)
+*/
+func SlidingWindow(limit int, window time.Duration) Algorithm {
+	if limit <= 0 || window <= 0 {
+		panic("ratelimit: invalid sliding window")
+	}
+	return &slidingWindow{limit: limit, window: int64(window)}
+}
+
+type slidingWindow struct {
+	limit  int
+	window int64
+}
+
+// The state is made of the start of the current window, the count of the
+// previous window and the count of the current window.
+func (sw *slidingWindow) Take(state []byte, now time.Time) ([]byte, Result) {
+	ns := now.UnixNano()
+	start := ns - ns%sw.window
+	var prev, curr uint32
+	if len(state) == 16 {
+		s := int64(binary.BigEndian.Uint64(state))
+		p, c := binary.BigEndian.Uint32(state[8:]), binary.BigEndian.Uint32(state[12:])
+		switch {
+		case s == start:
+			prev, curr = p, c
+		case s == start-sw.window:
+			prev = c
+		}
+	}
+
+	elapsed := ns - start
+	weight := float64(sw.window-elapsed) / float64(sw.window)
+	count := float64(prev)*weight + float64(curr)
+
+	r := Result{Limit: sw.limit, Reset: time.Duration(sw.window - elapsed)}
+	if count+1 <= float64(sw.limit) {
+		curr++
+		count++
+		r.Allowed = true
+	} else if prev > 0 && float64(curr) < float64(sw.limit) {
+		//wait for the previous window to slide out enough
+		over := count + 1 - float64(sw.limit)
+		r.RetryAfter = time.Duration(math.Ceil(over / float64(prev) * float64(sw.window)))
+	} else {
+		r.RetryAfter = r.Reset
+	}
+	r.Remaining = sw.limit - int(math.Ceil(count))
+	if r.Remaining < 0 {
+		r.Remaining = 0
+	}
+	if curr > 0 {
+		//the current window counts until the end of the next one
+		r.Reset = time.Duration(2*sw.window - elapsed)
+	}
+
+	next := make([]byte, 16)
+	binary.BigEndian.PutUint64(next, uint64(start))
+	binary.BigEndian.PutUint32(next[8:], prev)
+	binary.BigEndian.PutUint32(next[12:], curr)
+	return next, r
+}
+
+func (sw *slidingWindow) TTL() time.Duration {
+	return time.Duration(2 * sw.window)
+}
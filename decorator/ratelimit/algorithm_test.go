@@ -0,0 +1,75 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator/ratelimit"
+)
+
+type step struct {
+	at        time.Duration // since the start
+	allowed   bool
+	remaining int
+	retry     time.Duration
+}
+
+func runSteps(t *testing.T, name string, alg ratelimit.Algorithm, steps []step) {
+	start := time.Unix(1000, 0)
+	var state []byte
+	for i, s := range steps {
+		var r ratelimit.Result
+		state, r = alg.Take(state, start.Add(s.at))
+		if r.Allowed != s.allowed || r.Remaining != s.remaining || r.RetryAfter != s.retry {
+			t.Errorf("%s %d. Take failed, got: %+v, want: %+v", name, i, r, s)
+		}
+	}
+}
+
+//Test cases for the token bucket
+func TestTokenBucket(t *testing.T) {
+	runSteps(t, "TokenBucket", ratelimit.TokenBucket(2, time.Second, 3), []step{
+		{0, true, 2, 0},
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, 500 * time.Millisecond},
+		{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+		{500 * time.Millisecond, true, 0, 0},
+		{10 * time.Second, true, 2, 0},
+	})
+}
+
+//Test cases for the sliding window
+func TestSlidingWindow(t *testing.T) {
+	//the windows start at multiples of 10s, start is 1000s
+	runSteps(t, "SlidingWindow", ratelimit.SlidingWindow(4, 10*time.Second), []step{
+		{0, true, 3, 0},
+		{1 * time.Second, true, 2, 0},
+		{2 * time.Second, true, 1, 0},
+		{3 * time.Second, true, 0, 0},
+		{4 * time.Second, false, 0, 6 * time.Second},
+		//the previous window counts 4 * (10-5)/10 = 2
+		{15 * time.Second, true, 1, 0},
+		//the previous window counts 4 * (10-8)/10 = 0.8
+		{18 * time.Second, true, 1, 0},
+		{18 * time.Second, true, 0, 0},
+		{18 * time.Second, false, 0, 2 * time.Second},
+		{40 * time.Second, true, 3, 0},
+	})
+}
+
+func TestTokenBucketReset(t *testing.T) {
+	alg := ratelimit.TokenBucket(1, time.Second, 5)
+	var r ratelimit.Result
+	var state []byte
+	for i := 0; i < 2; i++ {
+		state, r = alg.Take(state, time.Unix(0, 0))
+	}
+	if r.Limit != 5 || r.Reset != 2*time.Second || alg.TTL() != 5*time.Second {
+		t.Errorf("TokenBucket reset failed, got: %+v %v", r, alg.TTL())
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// KeyFunc returns the key the request is limited by, the requests with an
+// empty key are not limited.
+type KeyFunc func(c ghttp.Ctx, h *ghttp.Http) string
+
+// ByIP limits by the client IP of the connection. Use ByHeader behind a
+// trusted proxy that sets the client IP, e.g. X-Real-IP.
+func ByIP(c ghttp.Ctx, h *ghttp.Http) string {
+	host, _, err := net.SplitHostPort(h.R.RemoteAddr)
+	if err != nil {
+		return h.R.RemoteAddr
+	}
+	return host
+}
+
+// ByHeader limits by the value of the request header, e.g. X-API-Key.
+func ByHeader(name string) KeyFunc {
+	return func(c ghttp.Ctx, h *ghttp.Http) string {
+		return h.R.Header.Get(name)
+	}
+}
+
+// ByRoute limits by the method and the route pattern matched by the Mux.
+func ByRoute(c ghttp.Ctx, h *ghttp.Http) string {
+	return h.R.Method + " " + h.Pattern
+}
+
+// ByKey limits by the string in the Ctx, e.g. the authenticated subject.
+func ByKey(k *ghttp.Key[string]) KeyFunc {
+	return func(c ghttp.Ctx, h *ghttp.Http) string {
+		v, _ := k.From(c)
+		return v
+	}
+}
+
+// Compose limits by the combination of the keys, e.g. per client per
+// route. The request is not limited if any of the keys is empty.
+func Compose(fns ...KeyFunc) KeyFunc {
+	return func(c ghttp.Ctx, h *ghttp.Http) string {
+		key := ""
+		for i, fn := range fns {
+			k := fn(c, h)
+			if k == "" {
+				return ""
+			}
+			if i > 0 {
+				key += "|"
+			}
+			key += k
+		}
+		return key
+	}
+}
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Algorithm Algorithm
+	Key       KeyFunc          // ByIP if nil
+	Store     Store            // a new MemoryStore with the Now clock if nil
+	Prefix    string           // prefixes the keys in the Store shared by several limiters
+	Now       func() time.Time // time.Now if nil
+}
+
+// CreateDecor creates a decorator that limits the requests by key with
+// the algorithm in a new MemoryStore, refer to CreateDecorWith for details.
+func CreateDecor(alg Algorithm, key KeyFunc) decorator.Decorator {
+	return CreateDecorWith(Options{Algorithm: alg, Key: key})
+}
+
+// CreateDecorWith creates a decorator that limits the requests.
+// The RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// are sent with each response. The rejected requests get a 429
+// ghttp.Response with the Retry-After header, sent as JSON.
+// The store errors are logged and the requests are let through.
+// Usage example:
+/*
+	api := mux.Group("/api", decorator.DecoratorChain{
+		ratelimit.CreateDecor(ratelimit.TokenBucket(10, time.Second, 20), ratelimit.ByHeader("X-API-Key")),
+	})
+	mux.Handle("/login", ghttp.Router{"POST": decorator.Decorate(login,
+		ratelimit.CreateDecor(ratelimit.SlidingWindow(5, time.Minute), ratelimit.ByIP))})
+*/
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.Algorithm == nil {
+		panic("ratelimit: nil Algorithm")
+	}
+	if opts.Key == nil {
+		opts.Key = ByIP
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Store == nil {
+		ms := NewMemoryStore(0)
+		ms.Now = opts.Now
+		opts.Store = ms
+	}
+	alg, ttl := opts.Algorithm, opts.Algorithm.TTL()
+
+	return decorator.Named("ratelimit", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			key := opts.Key(c, h)
+			if key == "" {
+				return next.ServeHTTPWithCtx(c, h)
+			}
+
+			var r Result
+			now := opts.Now()
+			err := opts.Store.Update(h.R.Context(), opts.Prefix+key, ttl, func(state []byte) []byte {
+				next, res := alg.Take(state, now)
+				r = res
+				return next
+			})
+			if err != nil {
+				if lc, ok := logging.GetLogger(c); ok {
+					lc.Logger().Error().Err(err).Str("key", key).Msg("rate limit store")
+				}
+				return next.ServeHTTPWithCtx(c, h)
+			}
+
+			hd := h.W.Header()
+			hd.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+			hd.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+			hd.Set("RateLimit-Reset", seconds(r.Reset))
+			if r.Allowed {
+				return next.ServeHTTPWithCtx(c, h)
+			}
+
+			hd.Set("Retry-After", seconds(r.RetryAfter))
+			h.Resp = ghttp.Response{Code: http.StatusTooManyRequests,
+				Message: http.StatusText(http.StatusTooManyRequests)}
+			hd.Set("Content-Type", "application/json; charset=utf-8")
+			h.W.WriteHeader(h.Resp.Code)
+			json.NewEncoder(h.W).Encode(h.Resp)
+			return c
+		})
+	})
+}
+
+// seconds returns the duration in whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
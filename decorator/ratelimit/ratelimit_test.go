@@ -0,0 +1,164 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/ratelimit"
+	"github.com/dlmc/golight/ghttp"
+)
+
+var thOK = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.W.Write([]byte("ok"))
+	return c
+})
+
+func serve(h ghttp.Handler, apiKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	if apiKey != "" {
+		r.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(r.Context(), &ghttp.Http{W: w, R: r})
+	return w
+}
+
+//Test cases for the rate limit decorator
+func TestRateLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	h := decorator.Decorate(thOK, ratelimit.CreateDecorWith(ratelimit.Options{
+		Algorithm: ratelimit.TokenBucket(1, time.Second, 2),
+		Key:       ratelimit.ByHeader("X-API-Key"),
+		Now:       func() time.Time { return now },
+	}))
+
+	testCases := []struct {
+		key       string
+		code      int
+		remaining string
+		retry     string
+	}{
+		{"a", 200, "1", ""},
+		{"a", 200, "0", ""},
+		{"a", 429, "0", "1"},
+		{"b", 200, "1", ""},
+		{"", 200, "", ""},
+	}
+	for i, tc := range testCases {
+		w := serve(h, tc.key)
+		if w.Code != tc.code || w.Header().Get("RateLimit-Remaining") != tc.remaining ||
+			w.Header().Get("Retry-After") != tc.retry {
+			t.Errorf("%d. RateLimit failed, got: %d %v", i, w.Code, w.Header())
+		}
+		if tc.code == 429 {
+			if want := `{"code":429,"message":"Too Many Requests"}`; strings.TrimSpace(w.Body.String()) != want ||
+				w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Reset") != "2" {
+				t.Errorf("%d. RateLimit response failed, got: %s %v", i, w.Body.String(), w.Header())
+			}
+		}
+	}
+
+	now = now.Add(time.Second)
+	if w := serve(h, "a"); w.Code != 200 {
+		t.Errorf("RateLimit refill failed, got: %d", w.Code)
+	}
+}
+
+type failStore struct{}
+
+func (failStore) Update(ctx context.Context, key string, ttl time.Duration, fn func([]byte) []byte) error {
+	return errors.New("store down")
+}
+
+func TestRateLimitStoreError(t *testing.T) {
+	h := decorator.Decorate(thOK, ratelimit.CreateDecorWith(ratelimit.Options{
+		Algorithm: ratelimit.SlidingWindow(1, time.Minute),
+		Store:     failStore{},
+	}))
+	for i := 0; i < 3; i++ {
+		if w := serve(h, ""); w.Code != http.StatusOK {
+			t.Errorf("RateLimit store error failed, got: %d", w.Code)
+		}
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	userKey := ghttp.NewKey[string]("user")
+	r := httptest.NewRequest("GET", "/users/7", nil)
+	h := &ghttp.Http{R: r, Pattern: "/users/{id}"}
+	c := userKey.With(r.Context(), "u1")
+
+	key := ratelimit.Compose(ratelimit.ByIP, ratelimit.ByRoute, ratelimit.ByKey(userKey))(c, h)
+	if key != "192.0.2.1|GET /users/{id}|u1" {
+		t.Errorf("KeyFuncs failed, got: %q", key)
+	}
+	if key := ratelimit.Compose(ratelimit.ByIP, ratelimit.ByHeader("X-API-Key"))(c, h); key != "" {
+		t.Errorf("KeyFuncs empty failed, got: %q", key)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := ratelimit.NewMemoryStore(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte {
+					return append(state, 1)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	var n int
+	s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte {
+		n = len(state)
+		return state
+	})
+	if n != 800 || s.Len() != 1 {
+		t.Errorf("MemoryStore failed, got: %d %d", n, s.Len())
+	}
+
+	s.Update(context.Background(), "k", -time.Second, func(state []byte) []byte { return state })
+	s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte {
+		n = len(state)
+		return state
+	})
+	if n != 0 {
+		t.Errorf("MemoryStore expiry failed, got: %d", n)
+	}
+}
+
+//Test cases for the clock of the MemoryStore expiries
+func TestMemoryStoreNow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := ratelimit.NewMemoryStore(1)
+	s.Now = func() time.Time { return now }
+	s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte { return []byte{1} })
+
+	var got []byte
+	now = now.Add(59 * time.Second)
+	s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte { got = state; return state })
+	if len(got) != 1 {
+		t.Errorf("MemoryStore Now failed, expired early: %v", got)
+	}
+	now = now.Add(2 * time.Minute)
+	s.Update(context.Background(), "k", time.Minute, func(state []byte) []byte { got = state; return state })
+	if got != nil {
+		t.Errorf("MemoryStore Now failed, not expired: %v", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// Store keeps the Algorithm state of the keys.
+// Implementations must be safe for concurrent use. A shared backend, e.g.
+// Redis, can implement Update with an optimistic transaction
+// (WATCH / GET / MULTI / SET PX / EXEC) retried on conflict, as fn is
+// side effect free.
+type Store interface {
+	// Update atomically replaces the state of the key by the one returned
+	// by fn, and keeps it for ttl. state is nil for a new or expired key.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) []byte) error
+}
+
+// MemoryStore is the in-memory Store, sharded to reduce the lock contention.
+// The expired keys are swept while the store is updated.
+type MemoryStore struct {
+	Now func() time.Time // the clock of the expiries, time.Now if nil
+
+	seed   maphash.Seed
+	shards []memShard
+}
+
+type memShard struct {
+	mu      sync.Mutex
+	entries map[string]memEntry
+	ops     int
+}
+
+type memEntry struct {
+	state   []byte
+	expires time.Time
+}
+
+// The number of updates of a shard between two sweeps
+const sweepEvery = 1024
+
+// NewMemoryStore creates a MemoryStore with the number of shards, 64 if
+// shards <= 0.
+func NewMemoryStore(shards int) *MemoryStore {
+	if shards <= 0 {
+		shards = 64
+	}
+	s := &MemoryStore{seed: maphash.MakeSeed(), shards: make([]memShard, shards)}
+	for i := range s.shards {
+		s.shards[i].entries = map[string]memEntry{}
+	}
+	return s
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state []byte) []byte) error {
+	sh := &s.shards[maphash.String(s.seed, key)%uint64(len(s.shards))]
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.ops++; sh.ops >= sweepEvery {
+		sh.ops = 0
+		for k, e := range sh.entries {
+			if !now.Before(e.expires) {
+				delete(sh.entries, k)
+			}
+		}
+	}
+	var state []byte
+	if e, ok := sh.entries[key]; ok && now.Before(e.expires) {
+		state = e.state
+	}
+	sh.entries[key] = memEntry{state: fn(state), expires: now.Add(ttl)}
+	return nil
+}
+
+// Len returns the number of keys in the store, including the expired keys
+// not swept yet.
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].entries)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
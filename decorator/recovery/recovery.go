@@ -0,0 +1,98 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/ghttp"
+	"github.com/dlmc/golight/logger"
+)
+
+// Panic is the error recovered from a panic, it is set into h.Err.
+type Panic struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // the stack trace of the goroutine that panicked
+}
+
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Reporter reports the recovered panics to an error sink.
+type Reporter func(c ghttp.Ctx, h *ghttp.Http, p *Panic)
+
+// CreateDecor creates a decorator that recovers from the panics of the
+// decorators and handlers it decorates.
+// The panic is logged with its stack trace and the request details
+// through h.Log, with the fields added by the inner decorators, or the
+// standard logger if there is no logger. A 500 ghttp.Response is sent if nothing has been written
+// yet, and the panic is passed to the reporters, e.g. to send it to an
+// error tracking service.
+// The recovery decorator should be the outermost one, but for logging:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), recovery.CreateDecor(), logging.CreateDecor(lc))
+*/
+// http.ErrAbortHandler is not recovered, so that net/http aborts the
+// response as intended.
+func CreateDecor(reporters ...Reporter) decorator.Decorator {
+	return decorator.Named("recovery", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) (rc ghttp.Ctx) {
+			w := ghttp.WrapWriter(h.W)
+			orig := h.W
+			h.W = w
+			defer func() {
+				h.W = orig
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				p := &Panic{Value: rec, Stack: debug.Stack()}
+				h.Err = p
+				logPanic(c, h, p)
+				if !w.Written() {
+					h.Resp = ghttp.Response{Code: http.StatusInternalServerError,
+						Message: http.StatusText(http.StatusInternalServerError)}
+					w.Header().Set("Content-Type", "application/json; charset=utf-8")
+					w.WriteHeader(h.Resp.Code)
+					json.NewEncoder(w).Encode(h.Resp)
+				}
+				for _, report := range reporters {
+					report(c, h, p)
+				}
+				rc = c
+			}()
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+func logPanic(c ghttp.Ctx, h *ghttp.Http, p *Panic) {
+	method, path, remote := "", "", ""
+	if h.R != nil {
+		method, path, remote = h.R.Method, h.R.URL.Path, h.R.RemoteAddr
+	}
+	//h.Log has the fields added by the inner decorators, unlike the Ctx
+	lc := h.Log
+	if _, ok := logging.GetLogger(c); !ok && !lc.Logger().Enabled(logger.LogError) {
+		log.Printf("golight: panic serving %s %s %s: %v\n%s", remote, method, path, p.Value, p.Stack)
+		return
+	}
+	lc.Str("method", method).
+		Str("path", path).
+		Str("remote", remote).
+		Str("panic", fmt.Sprint(p.Value)).
+		Str("stack", string(p.Stack)).
+		Logger().Error().Msg("panic recovered")
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package recovery_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/decorator/recovery"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Http request handler functions that panic
+var thPanic = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	panic("boom")
+})
+
+var thFieldPanic = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	logging.WithStr(c, h, "request_id", "r-1")
+	panic("boom")
+})
+
+var thWritePanic = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.W.WriteHeader(http.StatusAccepted)
+	h.W.Write([]byte("partial"))
+	panic("boom")
+})
+
+func TestRecovery(t *testing.T) {
+	out := &bytes.Buffer{}
+	var reported *recovery.Panic
+	report := func(c ghttp.Ctx, h *ghttp.Http, p *recovery.Panic) { reported = p }
+
+	mux := ghttp.NewMux()
+	mux.Handle("/panic", ghttp.Router{"GET": decorator.Decorate(thFieldPanic,
+		recovery.CreateDecor(report), logging.CreateDecor(logging.NewContext(out)))})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if want := `{"code":500,"message":"Internal Server Error"}` + "\n"; w.Code != 500 || w.Body.String() != want {
+		t.Errorf("Recovery failed, got: %d %s, want: 500 %s", w.Code, w.Body.String(), want)
+	}
+	if reported == nil || reported.Value != "boom" || len(reported.Stack) == 0 {
+		t.Errorf("Recovery report failed, got: %+v", reported)
+	}
+
+	var entry map[string]string
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("Recovery log failed: %v %s", err, out.String())
+	}
+	if entry["l"] != "error" || entry["m"] != "panic recovered" || entry["panic"] != "boom" ||
+		entry["method"] != "GET" || entry["path"] != "/panic" || entry["request_id"] != "r-1" || !strings.Contains(entry["stack"], "recovery_test") {
+		t.Errorf("Recovery log failed, got: %v", entry)
+	}
+}
+
+//Test cases for the standard logger, without logging.CreateDecor
+func TestRecoveryStdLog(t *testing.T) {
+	out := &bytes.Buffer{}
+	log.SetOutput(out)
+	defer log.SetOutput(os.Stderr)
+	h := decorator.Decorate(thPanic, recovery.CreateDecor())
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: httptest.NewRequest("GET", "/std", nil)})
+	if w.Code != 500 || !strings.Contains(out.String(), "golight: panic serving") || !strings.Contains(out.String(), "GET /std: boom") {
+		t.Errorf("Recovery std log failed, got: %d %s", w.Code, out.String())
+	}
+}
+
+func TestRecoveryWritten(t *testing.T) {
+	h := decorator.Decorate(thWritePanic, recovery.CreateDecor())
+	w := httptest.NewRecorder()
+	hp := &ghttp.Http{W: w, R: httptest.NewRequest("GET", "/", nil)}
+	h.ServeHTTPWithCtx(nil, hp)
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("Recovery failed, got: %d %s, want: 202 partial", w.Code, w.Body.String())
+	}
+	if _, ok := hp.Err.(*recovery.Panic); !ok || hp.W != w {
+		t.Errorf("Recovery failed, got Err: %v", hp.Err)
+	}
+}
+
+func TestRecoveryAbort(t *testing.T) {
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		panic(http.ErrAbortHandler)
+	}), recovery.CreateDecor())
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("Recovery recovered ErrAbortHandler, got: %v", rec)
+		}
+	}()
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: httptest.NewRecorder(), R: httptest.NewRequest("GET", "/", nil)})
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package requestid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// DefaultHeader is the header the request ID is read from and echoed in.
+const DefaultHeader = "X-Request-ID"
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Header   string               // DefaultHeader if empty
+	LogField string               // the field added to the logger, "request_id" if empty
+	Generate func() string        // NewUUID if nil
+	Valid    func(id string) bool // Valid if nil, the invalid incoming IDs are replaced
+}
+
+// requestID is the ID with the header it is forwarded in.
+type requestID struct {
+	id, header string
+}
+
+// Internal typed key
+var idKey = ghttp.NewKey[requestID]("requestid")
+
+// GetID returns the request ID in the Ctx and whether it was found.
+// Prior to call GetID, the request will have to be decorated
+// by the decor created by requestid.CreateDecor
+func GetID(c ghttp.Ctx) (string, bool) {
+	rid, ok := idKey.From(c)
+	return rid.id, ok
+}
+
+// CreateDecor creates a decorator that tags the request with an ID, refer
+// to CreateDecorWith for details.
+func CreateDecor() decorator.Decorator {
+	return CreateDecorWith(Options{})
+}
+
+// CreateDecorWith creates a decorator that tags the request with an ID.
+// The incoming X-Request-ID is used when it is valid, otherwise a UUIDv4
+// is generated. The ID is stored in the Ctx, echoed in the response
+// header and added as a field to the logger of logging.CreateDecor, so
+// the logging decorator has to be outer:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), requestid.CreateDecor(), logging.CreateDecor(lc))
+*/
+// Use Transport or SetHeader to forward the ID to the downstream services.
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.Header == "" {
+		opts.Header = DefaultHeader
+	}
+	if opts.LogField == "" {
+		opts.LogField = "request_id"
+	}
+	if opts.Generate == nil {
+		opts.Generate = NewUUID
+	}
+	if opts.Valid == nil {
+		opts.Valid = Valid
+	}
+	return decorator.Named("requestid", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			id := ""
+			if h.R != nil {
+				id = h.R.Header.Get(opts.Header)
+			}
+			if !opts.Valid(id) {
+				id = opts.Generate()
+			}
+			c = idKey.With(c, requestID{id, opts.Header})
+			c = logging.WithStr(c, h, opts.LogField, id)
+			h.W.Header().Set(opts.Header, id)
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// Valid reports whether the incoming ID can be used: 1 to 128 printable
+// ASCII characters without spaces, so it can not inject into the headers
+// nor the logs.
+func Valid(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] >= 0x7f || id[i] == '"' || id[i] == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUID returns a random UUID version 4, refer to RFC 4122.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// SetHeader sets the request ID in the Ctx into the header of the
+// outbound request, the Header of the Options of the decorator.
+// Usage example:
+/*
+	req, _ := http.NewRequest("GET", "http://inventory/items", nil)
+	requestid.SetHeader(c, req)
+*/
+func SetHeader(c ghttp.Ctx, req *http.Request) {
+	if rid, ok := idKey.From(c); ok {
+		req.Header.Set(rid.header, rid.id)
+	}
+}
+
+// Transport is an http.RoundTripper that forwards the request ID in the
+// context of the outbound request to the downstream services, in the
+// Header of the Options of the decorator.
+// Usage example:
+/*
+	client := &http.Client{Transport: &requestid.Transport{}}
+	req, _ := http.NewRequestWithContext(c, "GET", "http://inventory/items", nil)
+	resp, err := client.Do(req)
+*/
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if rid, ok := idKey.From(req.Context()); ok && req.Header.Get(rid.header) == "" {
+		//RoundTrippers must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set(rid.header, rid.id)
+	}
+	return base.RoundTrip(req)
+}
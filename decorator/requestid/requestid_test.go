@@ -0,0 +1,123 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package requestid_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/decorator/requestid"
+	"github.com/dlmc/golight/ghttp"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+//Http request handler that logs and returns the request id
+var thID = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	id, _ := requestid.GetID(c)
+	h.Log.Logger().Info().Msg("handled")
+	h.W.Write([]byte(id))
+	return c
+})
+
+//Test cases for the request id decorator
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		incoming string
+		generate bool
+	}{
+		{"", true},
+		{"abc-123", false},
+		{"bad id", true},
+		{"bad\"id", true},
+	}
+
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		h := decorator.Decorate(thID, requestid.CreateDecor(), logging.CreateDecor(logging.NewContext(out)))
+		r := httptest.NewRequest("GET", "/", nil)
+		if tc.incoming != "" {
+			r.Header.Set("X-Request-ID", tc.incoming)
+		}
+		w := httptest.NewRecorder()
+		hp := &ghttp.Http{W: w, R: r}
+		h.ServeHTTPWithCtx(nil, hp)
+
+		id := w.Body.String()
+		if tc.generate && !uuidRe.MatchString(id) || !tc.generate && id != tc.incoming {
+			t.Errorf("%d. RequestID failed, got: %q, incoming: %q", i, id, tc.incoming)
+		}
+		if got := w.Header().Get("X-Request-ID"); got != id {
+			t.Errorf("%d. RequestID header failed, got: %q, want: %q", i, got, id)
+		}
+		var entry map[string]string
+		if err := json.Unmarshal(out.Bytes(), &entry); err != nil || entry["request_id"] != id {
+			t.Errorf("%d. RequestID log failed, got: %s, want: %q", i, out.String(), id)
+		}
+	}
+}
+
+func TestRequestIDNoLogger(t *testing.T) {
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		id, _ := requestid.GetID(c)
+		h.W.Write([]byte(id))
+		return c
+	}), requestid.CreateDecorWith(requestid.Options{Header: "X-Trace", Generate: func() string { return "gen" }}))
+	w := httptest.NewRecorder()
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: httptest.NewRequest("GET", "/", nil)})
+	if w.Body.String() != "gen" || w.Header().Get("X-Trace") != "gen" {
+		t.Errorf("RequestID options failed, got: %q %q", w.Body.String(), w.Header().Get("X-Trace"))
+	}
+}
+
+func TestTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request-ID")))
+	}))
+	defer ts.Close()
+
+	var got string
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		client := &http.Client{Transport: &requestid.Transport{}}
+		req, _ := http.NewRequestWithContext(c, "GET", ts.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b := &bytes.Buffer{}
+		b.ReadFrom(resp.Body)
+		got = b.String()
+		return c
+	}), requestid.CreateDecor())
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-ID", "down-1")
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: httptest.NewRecorder(), R: r})
+	if got != "down-1" {
+		t.Errorf("Transport failed, got: %q, want: down-1", got)
+	}
+}
+
+//Test cases for the forwarding in the configured header
+func TestSetHeaderOptions(t *testing.T) {
+	var req *http.Request
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		req, _ = http.NewRequest("GET", "http://inventory/items", nil)
+		requestid.SetHeader(c, req)
+		return c
+	}), requestid.CreateDecorWith(requestid.Options{Header: "X-Trace"}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Trace", "down-2")
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: httptest.NewRecorder(), R: r})
+	if got := req.Header.Get("X-Trace"); got != "down-2" || req.Header.Get(requestid.DefaultHeader) != "" {
+		t.Errorf("SetHeader failed, got: %q %v", got, req.Header)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// The binary encoders below only encode the JSON form of the values
+// returned by generic, which is all the respond envelope needs.
+
+// number returns the json.Number as an int64, an uint64 or a float64.
+func number(n json.Number) interface{} {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u
+	}
+	f, _ := strconv.ParseFloat(string(n), 64)
+	return f
+}
+
+func appendMsgPack(b []byte, g interface{}) []byte {
+	switch v := g.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case json.Number:
+		switch n := number(v).(type) {
+		case int64:
+			return appendMsgPackInt(b, n)
+		case uint64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(n))
+		}
+	case string:
+		switch l := len(v); {
+		case l < 32:
+			b = append(b, 0xa0|byte(l))
+		case l <= math.MaxUint8:
+			b = append(b, 0xd9, byte(l))
+		case l <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(l))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(l))
+		}
+		return append(b, v...)
+	case []interface{}:
+		b = appendMsgPackLen(b, len(v), 0x90, 0xdc)
+		for _, e := range v {
+			b = appendMsgPack(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		b = appendMsgPackLen(b, len(v), 0x80, 0xde)
+		for _, k := range sortedKeys(v) {
+			b = appendMsgPack(b, k)
+			b = appendMsgPack(b, v[k])
+		}
+		return b
+	}
+	return append(b, 0xc0)
+}
+
+// appendMsgPackLen appends the fix, 16 or 32 bit array or map header.
+func appendMsgPackLen(b []byte, l int, fix, code16 byte) []byte {
+	switch {
+	case l < 16:
+		return append(b, fix|byte(l))
+	case l <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(l))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code16+1), uint32(l))
+}
+
+func appendMsgPackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i > 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i > 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i > 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i > 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// CBOR major types
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+)
+
+func appendCBOR(b []byte, g interface{}) []byte {
+	switch v := g.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case json.Number:
+		switch n := number(v).(type) {
+		case int64:
+			if n < 0 {
+				return appendCBORHead(b, cborNegInt, uint64(-1-n))
+			}
+			return appendCBORHead(b, cborUint, uint64(n))
+		case uint64:
+			return appendCBORHead(b, cborUint, n)
+		case float64:
+			return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(n))
+		}
+	case string:
+		return append(appendCBORHead(b, cborText, uint64(len(v))), v...)
+	case []interface{}:
+		b = appendCBORHead(b, cborArray, uint64(len(v)))
+		for _, e := range v {
+			b = appendCBOR(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		b = appendCBORHead(b, cborMap, uint64(len(v)))
+		for _, k := range sortedKeys(v) {
+			b = appendCBOR(b, k)
+			b = appendCBOR(b, v[k])
+		}
+		return b
+	}
+	return append(b, 0xf6)
+}
+
+// appendCBORHead appends the initial byte and the argument of a data item.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), n)
+}
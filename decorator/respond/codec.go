@@ -0,0 +1,304 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codec encodes the response for a media type.
+// Usage example:
+/*
+	//Send the protobuf messages set in h.Resp.Data as is
+	respond.Register(respond.Codec{
+		MediaType: "application/x-protobuf",
+		Raw:       true,
+		Encode: func(w io.Writer, v interface{}) error {
+			b, err := proto.Marshal(v.(proto.Message))
+			if err == nil {
+				_, err = w.Write(b)
+			}
+			return err
+		},
+	})
+*/
+type Codec struct {
+	MediaType   string // e.g. application/json, matched against the Accept header
+	ContentType string // the Content-Type header sent, MediaType if empty
+	Raw         bool   // encode h.Resp.Data instead of the h.Resp envelope
+	Encode      func(w io.Writer, v interface{}) error
+}
+
+func (cd Codec) contentType() string {
+	if cd.ContentType == "" {
+		return cd.MediaType
+	}
+	return cd.ContentType
+}
+
+// Registry holds the Codecs the respond decorator negotiates from.
+// It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs []Codec
+}
+
+// NewRegistry creates a Registry with the codecs. The first codec is
+// used when the request has no Accept header.
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{}
+	for _, cd := range codecs {
+		r.Register(cd)
+	}
+	return r
+}
+
+// Register adds the codec to the registry, replacing the codec registered
+// for the same media type.
+func (r *Registry) Register(cd Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cd.MediaType = strings.ToLower(cd.MediaType)
+	for i := range r.codecs {
+		if r.codecs[i].MediaType == cd.MediaType {
+			r.codecs[i] = cd
+			return
+		}
+	}
+	r.codecs = append(r.codecs, cd)
+}
+
+// MediaTypes returns the registered media types in registration order.
+func (r *Registry) MediaTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mts := make([]string, len(r.codecs))
+	for i, cd := range r.codecs {
+		mts[i] = cd.MediaType
+	}
+	return mts
+}
+
+// Negotiate returns the codec that best matches the Accept header.
+// The highest q-value wins, then the codec registered first. The first
+// codec is returned for an empty Accept header.
+// It returns false when no codec is acceptable.
+func (r *Registry) Negotiate(accept string) (Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.codecs) == 0 {
+		return Codec{}, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.codecs[0], true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := -1, 0.0
+	for i, cd := range r.codecs {
+		if q := quality(ranges, cd.MediaType); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if best < 0 {
+		return Codec{}, false
+	}
+	return r.codecs[best], true
+}
+
+type mediaRange struct {
+	typ, sub string
+	q        float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		i := strings.IndexByte(mt, '/')
+		if i < 0 {
+			continue
+		}
+		mr := mediaRange{typ: mt[:i], sub: mt[i+1:], q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				q, err := strconv.ParseFloat(v, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				mr.q = q
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	return ranges
+}
+
+// quality returns the q-value of the most specific range matching the
+// media type.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, sub, _ := strings.Cut(mediaType, "/")
+	q, spec := 0.0, -1
+	for _, mr := range ranges {
+		s := -1
+		switch {
+		case mr.typ == typ && mr.sub == sub:
+			s = 2
+		case mr.typ == typ && mr.sub == "*":
+			s = 1
+		case mr.typ == "*" && mr.sub == "*":
+			s = 0
+		}
+		if s > spec {
+			q, spec = mr.q, s
+		}
+	}
+	return q
+}
+
+// JSON encodes with encoding/json.
+var JSON = Codec{
+	MediaType:   "application/json",
+	ContentType: "application/json; charset=utf-8",
+	Encode: func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v) // will write "\n" at the end
+	},
+}
+
+// XML encodes the JSON form of the value as XML, so that maps and the
+// json tags are supported. The envelope is encoded as
+//	<response><code>200</code><message>OK</message><data>...</data></response>
+// Object members become elements, or <entry key="..."> when the member
+// name is not a valid XML name, array elements become <item> elements.
+var XML = Codec{
+	MediaType:   "application/xml",
+	ContentType: "application/xml; charset=utf-8",
+	Encode: func(w io.Writer, v interface{}) error {
+		g, err := generic(v)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		if err := encodeXML(enc, "response", g); err != nil {
+			return err
+		}
+		return enc.Flush()
+	},
+}
+
+// MsgPack encodes the JSON form of the value as MessagePack.
+var MsgPack = Codec{
+	MediaType: "application/msgpack",
+	Encode: func(w io.Writer, v interface{}) error {
+		return encodeGeneric(w, v, appendMsgPack)
+	},
+}
+
+// CBOR encodes the JSON form of the value as CBOR, refer to RFC 8949.
+var CBOR = Codec{
+	MediaType: "application/cbor",
+	Encode: func(w io.Writer, v interface{}) error {
+		return encodeGeneric(w, v, appendCBOR)
+	},
+}
+
+// DefaultRegistry is the Registry used by CreateDecor.
+var DefaultRegistry = NewRegistry(JSON, XML, MsgPack, CBOR)
+
+// Register registers the codec into the DefaultRegistry.
+func Register(cd Codec) {
+	DefaultRegistry.Register(cd)
+}
+
+// generic returns the JSON form of v made of map[string]interface{},
+// []interface{}, json.Number, string, bool and nil.
+func generic(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var g interface{}
+	err = dec.Decode(&g)
+	return g, err
+}
+
+func encodeGeneric(w io.Writer, v interface{}, appendFn func([]byte, interface{}) []byte) error {
+	g, err := generic(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(appendFn(nil, g))
+	return err
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func encodeXML(enc *xml.Encoder, name string, g interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !isXMLName(name) {
+		start = xml.StartElement{Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := g.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			if err := encodeXML(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := encodeXML(enc, "item", e); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case i > 0 && (c == '-' || c == '.' || (c >= '0' && c <= '9')):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package respond_test
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//An Http request handler function with nested data
+var thData = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.Resp.Data = map[string]interface{}{"a b": []interface{}{1, -300, 1.5, "s"}, "n": nil, "t": true}
+	return c
+})
+
+//Process negotiated results
+func tResultAccept(t *testing.T, h ghttp.Handler, accept string, wantCode int, wantType, want string) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: r})
+
+	if w.Code != wantCode {
+		t.Errorf("Accept %q failed, got: %d, want: %d", accept, w.Code, wantCode)
+	}
+	if got := w.Header().Get("Content-Type"); got != wantType {
+		t.Errorf("Accept %q failed, got: %s, want: %s", accept, got, wantType)
+	}
+	if got := w.Body.String(); got != want {
+		t.Errorf("Accept %q failed, got: %q, want: %q", accept, got, want)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Accept %q failed, got Vary: %s", accept, got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	reg := respond.NewRegistry(respond.JSON, respond.XML, respond.MsgPack)
+	tests := []struct {
+		accept, want string
+		ok           bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"application/json;q=0.5, application/xml;q=0.8", "application/xml", true},
+		{"application/*;q=0.2, application/msgpack", "application/msgpack", true},
+		{"application/*, application/json;q=0", "application/xml", true},
+		{"text/html, */*;q=0.1", "application/json", true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+	}
+	for _, tt := range tests {
+		cd, ok := reg.Negotiate(tt.accept)
+		if ok != tt.ok || cd.MediaType != tt.want {
+			t.Errorf("Negotiate %q failed, got: %s %v, want: %s %v", tt.accept, cd.MediaType, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRespondCodecs(t *testing.T) {
+	h := decorator.Decorate(thData, respond.CreateDecor())
+
+	tResultAccept(t, h, "", http.StatusOK, "application/json; charset=utf-8",
+		`{"code":200,"data":{"a b":[1,-300,1.5,"s"],"n":null,"t":true}}`+"\n")
+	tResultAccept(t, h, "application/xml", http.StatusOK, "application/xml; charset=utf-8",
+		`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+			`<response><code>200</code><data><entry key="a b"><item>1</item><item>-300</item><item>1.5</item><item>s</item></entry><n></n><t>true</t></data></response>`)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/msgpack")
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: r})
+	// {"code":200,"data":{"a b":[1,-300,1.5,"s"],"n":nil,"t":true}}
+	want := "82" + "a4636f6465" + "ccc8" + "a464617461" + "83" +
+		"a3612062" + "94" + "01" + "d1fed4" + "cb3ff8000000000000" + "a173" +
+		"a16e" + "c0" + "a174" + "c3"
+	if got := hex.EncodeToString(w.Body.Bytes()); got != want {
+		t.Errorf("MsgPack failed, got: %s, want: %s", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	r.Header.Set("Accept", "application/cbor")
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: w, R: r})
+	want = "a2" + "64636f6465" + "18c8" + "6464617461" + "a3" +
+		"63612062" + "84" + "01" + "39012b" + "fb3ff8000000000000" + "6173" +
+		"616e" + "f6" + "6174" + "f5"
+	if got := hex.EncodeToString(w.Body.Bytes()); got != want {
+		t.Errorf("CBOR failed, got: %s, want: %s", got, want)
+	}
+
+	tResultAccept(t, h, "text/html", http.StatusNotAcceptable, "application/problem+json",
+		`{"detail":"supported media types: application/json, application/xml, application/msgpack, application/cbor","status":406,"title":"Not Acceptable"}`+"\n")
+}
+
+func TestRespondRawCodec(t *testing.T) {
+	reg := respond.NewRegistry(respond.JSON, respond.Codec{
+		MediaType: "text/plain",
+		Raw:       true,
+		Encode: func(w io.Writer, v interface{}) error {
+			_, err := io.WriteString(w, v.(string))
+			return err
+		},
+	})
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		h.Resp.Data = "raw data"
+		return c
+	}), respond.CreateDecorWith(reg))
+
+	tResultAccept(t, h, "text/plain", http.StatusOK, "text/plain", "raw data")
+	tResultAccept(t, h, "application/json", http.StatusOK, "application/json; charset=utf-8",
+		`{"code":200,"data":"raw data"}`+"\n")
+}
@@ -6,24 +6,92 @@ package respond
 
 import (
 	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
 	"github.com/dlmc/golight/ghttp"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
 )
 
 // CreateDecor creates a respond decorator that will send out http response using
 // the content of h.Resp struct
+// The response is encoded with the codec of the DefaultRegistry negotiated
+// from the Accept header, JSON by default. Refer to CreateDecorWith for details.
 func CreateDecor() decorator.Decorator {
-	return func(next ghttp.Handler) ghttp.Handler {
+	return CreateDecorWith(DefaultRegistry)
+}
+
+// CreateDecorWith creates a respond decorator that encodes h.Resp with the
+// codec of the registry that best matches the Accept header of the request.
+// The request is answered with 406 without calling the handler when no
+// codec is acceptable.
+// A zero h.Resp.Code is sent as 200.
+// The error set in h.Err replaces h.Resp:
+//	*ghttp.Problem     is sent as application/problem+json, refer to RFC 7807
+//	ghttp.StatusError  e.g. the ghttp.BindError returned by h.Bind, is sent
+//	                   in h.Resp with its status code and message. The Data
+//	                   of a ghttp.DataError, e.g. validate.Errors, is sent as well.
+//	other errors       are sent as a 500 problem without any detail, so that
+//	                   the internals do not leak. The error is logged with
+//	                   the logger of logging.CreateDecor if any.
+func CreateDecorWith(reg *Registry) decorator.Decorator {
+	return decorator.Named("respond", func(next ghttp.Handler) ghttp.Handler {
 		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx{
-			c = next.ServeHTTPWithCtx(c, h)
 			w := h.W
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Add("Vary", "Accept")
+			cd, ok := reg.Negotiate(h.R.Header.Get("Accept"))
+			if !ok {
+				writeProblem(w, &ghttp.Problem{Status:http.StatusNotAcceptable,
+					Detail:"supported media types: " + strings.Join(reg.MediaTypes(), ", ")})
+				return c
+			}
+			c = next.ServeHTTPWithCtx(c, h)
+			if h.Err != nil {
+				if p := problemOf(c, h); p != nil {
+					writeProblem(w, p)
+					return c
+				}
+			}
+			if h.Resp.Code == 0 {
+				h.Resp.Code = http.StatusOK
+			}
+			w.Header().Set("Content-Type", cd.contentType())
 			w.WriteHeader(h.Resp.Code)
-			//out, _ := json.Marshal(h.Resp)
-			//w.Write(out)	   //will not with "\n" at the end
-			json.NewEncoder(w).Encode(h.Resp)	 // will write "\n" at the end
+			if cd.Raw {
+				cd.Encode(w, h.Resp.Data)
+			} else {
+				cd.Encode(w, h.Resp)
+			}
 			return c
 		})
-	}		
+	})		
 }
 
+// problemOf returns the Problem to send for h.Err, or nil when h.Err is
+// sent in h.Resp.
+func problemOf(c ghttp.Ctx, h *ghttp.Http) *ghttp.Problem {
+	var p *ghttp.Problem
+	if errors.As(h.Err, &p) {
+		return p
+	}
+	var se ghttp.StatusError
+	if errors.As(h.Err, &se) {
+		h.Resp = ghttp.Response{Code:se.StatusCode(), Message:se.Error()}
+		var de ghttp.DataError
+		if errors.As(h.Err, &de) {
+			h.Resp.Data = de.ResponseData()
+		}
+		return nil
+	}
+	if lc, ok := logging.GetLogger(c); ok {
+		lc.Logger().Error().Err(h.Err).Msg("internal error")
+	}
+	return &ghttp.Problem{Status:http.StatusInternalServerError}
+}
+
+func writeProblem(w http.ResponseWriter, p *ghttp.Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.StatusCode())
+	json.NewEncoder(w).Encode(p)
+}
@@ -5,13 +5,19 @@
 package respond_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"net/http"
 	"io/ioutil"
 	"net/http/httptest"
 	"github.com/dlmc/golight/decorator"
 	"github.com/dlmc/golight/ghttp"
+	"github.com/dlmc/golight/decorator/logging"
 	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/validate"
 //	"encoding/json"
 )
 
@@ -64,3 +70,121 @@ func TestLoggingDecorator(t *testing.T) {
 }
 
 
+
+
+//An Http request handler function that fails to bind the request
+var thBind = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	var req struct {
+		Count int `query:"count"`
+	}
+	if err := h.Bind(&req); err != nil {
+		h.Err = err
+		return c
+	}
+	h.Resp.Code = http.StatusOK
+	return c
+})
+
+func TestRespondBindError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/test", ghttp.Router{"GET":decorator.Decorate(thBind, respond.CreateDecor())})
+	
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	
+	res,err := http.Get(ts.URL+"/test?count=x")
+	if err == nil && res.StatusCode != http.StatusBadRequest {
+		t.Errorf("tResult failed, got: %d, want: %d", res.StatusCode, http.StatusBadRequest)
+	}
+	strRes := `{"code":400,"message":"bind count: strconv.ParseInt: parsing \"x\": invalid syntax"}`+"\n"
+	tResult1(t, res, err, strRes)
+}
+
+
+//An Http request handler function that fails to validate the request
+var thValidate = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	var req struct {
+		Count int `query:"count" json:"count" validate:"min=1"`
+	}
+	h.Bind(&req)
+	if err := validate.Struct(&req); err != nil {
+		h.Err = err
+		return c
+	}
+	h.Resp.Code = http.StatusOK
+	return c
+})
+
+func TestRespondValidateError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/test", ghttp.Router{"GET":decorator.Decorate(thValidate, respond.CreateDecor())})
+	
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	
+	res,err := http.Get(ts.URL+"/test?count=0")
+	if err == nil && res.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("tResult failed, got: %d, want: %d", res.StatusCode, http.StatusUnprocessableEntity)
+	}
+	strRes := `{"code":422,"message":"validate: count must be at least 1","data":[{"field":"count","rule":"min","param":"1","message":"must be at least 1"}]}`+"\n"
+	tResult1(t, res, err, strRes)
+}
+
+
+func tResultProblem(t *testing.T, h ghttp.Handler, wantCode int, wantType, want string) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	decorator.Decorate(h, respond.CreateDecor()).ServeHTTPWithCtx(context.Background(), &ghttp.Http{W:w, R:r})
+
+	if w.Code != wantCode {
+		t.Errorf("tResult failed, got: %d, want: %d", w.Code, wantCode)
+	}
+	if got := w.Header().Get("Content-Type"); got != wantType {
+		t.Errorf("tResult failed, got: %s, want: %s", got, wantType)
+	}
+	if got := w.Body.String(); got != want {
+		t.Errorf("tResult failed, got: %s, want: %s", got, want)
+	}
+}
+
+func TestRespondProblem(t *testing.T) {
+	t.Run("Problem", func(t *testing.T) {
+		h := ghttp.ErrHandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) (ghttp.Ctx, error) {
+			return c, fmt.Errorf("wrapped: %w", &ghttp.Problem{
+				Type: "https://example.com/probs/out-of-credit", Status: http.StatusForbidden,
+				Detail: "balance is 30", Instance: "/account/1", Extensions: map[string]interface{}{"balance":30},
+			})
+		})
+		tResultProblem(t, h, http.StatusForbidden, "application/problem+json",
+			`{"balance":30,"detail":"balance is 30","instance":"/account/1","status":403,"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`+"\n")
+	})
+	t.Run("NewProblem", func(t *testing.T) {
+		h := ghttp.ErrHandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) (ghttp.Ctx, error) {
+			return c, ghttp.NewProblem(http.StatusNotFound, "no such user")
+		})
+		tResultProblem(t, h, http.StatusNotFound, "application/problem+json",
+			`{"detail":"no such user","status":404,"title":"Not Found"}`+"\n")
+	})
+	t.Run("InternalError", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		h := decorator.Decorate(ghttp.ErrHandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) (ghttp.Ctx, error) {
+			return c, errors.New("db password is secret")
+		}), respond.CreateDecor(), logging.CreateDecor(logging.NewContext(out)))
+		w := httptest.NewRecorder()
+		h.ServeHTTPWithCtx(context.Background(), &ghttp.Http{W:w, R:httptest.NewRequest("GET", "/", nil)})
+		want := `{"status":500,"title":"Internal Server Error"}`+"\n"
+		if w.Code != http.StatusInternalServerError || w.Body.String() != want {
+			t.Errorf("tResult failed, got: %d %s, want: 500 %s", w.Code, w.Body.String(), want)
+		}
+		if got, want := out.String(), `{"l":"error","e":"db password is secret","m":"internal error"}`+"\n"; got != want {
+			t.Errorf("tResult failed, got: %s, want: %s", got, want)
+		}
+	})
+	t.Run("ZeroCode", func(t *testing.T) {
+		h := ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.Resp.Data = "d"
+			return c
+		})
+		tResultProblem(t, h, http.StatusOK, "application/json; charset=utf-8", `{"code":200,"data":"d"}`+"\n")
+	})
+}
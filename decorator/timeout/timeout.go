@@ -0,0 +1,144 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package timeout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Timeout time.Duration // the time limit of the decorated handler
+	Status  int           // the status sent on timeout, 503 if 0
+	Message string        // the message sent on timeout, the status text if empty
+}
+
+// CreateDecor creates a decorator that limits the decorated handler to d,
+// refer to CreateDecorWith for details.
+func CreateDecor(d time.Duration) decorator.Decorator {
+	return CreateDecorWith(Options{Timeout: d})
+}
+
+// CreateDecorWith creates a decorator that limits the decorated handler to
+// opts.Timeout.
+// The handler gets a Ctx with the deadline, so it can stop its work on
+// c.Done(). Its response is buffered and sent when it returns in time.
+// Otherwise the decorator sends a ghttp.Response with opts.Status, 503 by
+// default or e.g. 504 for a gateway, and the handler's late writes to h.W
+// fail with http.ErrHandlerTimeout and are discarded.
+// The response is sent as JSON, so the timeout decorator should be outer
+// to respond.CreateDecor:
+/*
+	h := decorator.Decorate(hdl, respond.CreateDecor(), timeout.CreateDecor(2*time.Second),
+		recovery.CreateDecor(), logging.CreateDecor(lc))
+*/
+// The handler works on a copy of h, so its changes to h after the timeout
+// do not race with the outer decorators. A panic of the handler is
+// raised again by the decorator, for recovery.CreateDecor to catch it.
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.Status == 0 {
+		opts.Status = http.StatusServiceUnavailable
+	}
+	if opts.Message == "" {
+		opts.Message = http.StatusText(opts.Status)
+	}
+	return decorator.Named("timeout", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			if c == nil {
+				c = context.Background()
+			}
+			ctx, cancel := context.WithTimeout(c, opts.Timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: http.Header{}}
+			hc := *h
+			hc.W = tw
+			done := make(chan ghttp.Ctx, 1)
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						if p != http.ErrAbortHandler {
+							p = fmt.Sprintf("%v\n%s", p, debug.Stack())
+						}
+						panicked <- p
+					}
+				}()
+				done <- next.ServeHTTPWithCtx(ctx, &hc)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case rc := <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				w := h.W
+				*h = hc
+				h.W = w
+				dst := w.Header()
+				for k, vs := range tw.header {
+					dst[k] = vs
+				}
+				if tw.status != 0 {
+					w.WriteHeader(tw.status)
+				}
+				w.Write(tw.buf.Bytes())
+				return rc
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				h.Resp = ghttp.Response{Code: opts.Status, Message: opts.Message}
+				h.W.Header().Set("Content-Type", "application/json; charset=utf-8")
+				h.W.WriteHeader(opts.Status)
+				json.NewEncoder(h.W).Encode(h.Resp)
+				return c
+			}
+		})
+	})
+}
+
+// timeoutWriter buffers the response of the handler until it returns.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.header }
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = code
+}
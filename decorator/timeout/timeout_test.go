@@ -0,0 +1,81 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package timeout_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/timeout"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Http request handler that sleeps for the duration in the query
+func thSleep(writeErr chan error) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		d, _ := time.ParseDuration(h.Query.Get("sleep"))
+		time.Sleep(d)
+		h.W.Header().Set("X-Handler", "1")
+		h.W.WriteHeader(http.StatusCreated)
+		_, err := h.W.Write([]byte("done"))
+		h.Resp.Message = "handler"
+		if writeErr != nil {
+			writeErr <- err
+		}
+		return c
+	})
+}
+
+//Test cases for the timeout decorator
+func TestTimeout(t *testing.T) {
+	h := decorator.Decorate(thSleep(nil), timeout.CreateDecor(100*time.Millisecond))
+	w := httptest.NewRecorder()
+	hp := &ghttp.Http{W: w, R: httptest.NewRequest("GET", "/?sleep=1ms", nil)}
+	hp.Query = hp.R.URL.Query()
+	h.ServeHTTPWithCtx(nil, hp)
+
+	if w.Code != http.StatusCreated || w.Body.String() != "done" || w.Header().Get("X-Handler") != "1" {
+		t.Errorf("Timeout in time failed, got: %d %q", w.Code, w.Body.String())
+	}
+	if hp.Resp.Message != "handler" || hp.W != w {
+		t.Errorf("Timeout did not copy back the Http, got: %+v", hp.Resp)
+	}
+}
+
+func TestTimeoutExceeded(t *testing.T) {
+	writeErr := make(chan error, 1)
+	h := decorator.Decorate(thSleep(writeErr), timeout.CreateDecorWith(timeout.Options{
+		Timeout: 10 * time.Millisecond, Status: http.StatusGatewayTimeout}))
+	w := httptest.NewRecorder()
+	hp := &ghttp.Http{W: w, R: httptest.NewRequest("GET", "/?sleep=50ms", nil)}
+	hp.Query = hp.R.URL.Query()
+	h.ServeHTTPWithCtx(nil, hp)
+
+	if want := `{"code":504,"message":"Gateway Timeout"}`; w.Code != 504 || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("Timeout exceeded failed, got: %d %s, want: 504 %s", w.Code, w.Body.String(), want)
+	}
+	if err := <-writeErr; err != http.ErrHandlerTimeout {
+		t.Errorf("Timeout late write failed, got: %v", err)
+	}
+	if w.Header().Get("X-Handler") != "" || hp.Resp.Message != "Gateway Timeout" {
+		t.Errorf("Timeout leaked the late response, got: %v %+v", w.Header(), hp.Resp)
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	h := decorator.Decorate(ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		panic("boom")
+	}), timeout.CreateDecor(time.Second))
+	defer func() {
+		if p, _ := recover().(string); !strings.HasPrefix(p, "boom") {
+			t.Errorf("Timeout panic failed, got: %v", p)
+		}
+	}()
+	h.ServeHTTPWithCtx(nil, &ghttp.Http{W: httptest.NewRecorder(), R: httptest.NewRequest("GET", "/", nil)})
+}
@@ -0,0 +1,57 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Exporter receives the ended spans of the sampled traces.
+// Export is called on the request path, so the exporters sending the spans
+// over the network queue them and send them in the background.
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+	// Shutdown sends the queued spans and stops the exporter.
+	Shutdown(ctx context.Context) error
+}
+
+// MemoryExporter keeps the exported spans in memory, e.g. for the tests.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewMemoryExporter creates an empty MemoryExporter.
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+// Export implements Exporter.
+func (m *MemoryExporter) Export(ctx context.Context, spans []*Span) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+// Shutdown implements Exporter.
+func (m *MemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans returns the exported spans in the order of their end.
+func (m *MemoryExporter) Spans() []*Span {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Span(nil), m.spans...)
+}
+
+// Reset removes the exported spans.
+func (m *MemoryExporter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = nil
+}
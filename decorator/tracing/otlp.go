@@ -0,0 +1,299 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OTLPOptions configures NewOTLPExporter.
+type OTLPOptions struct {
+	// Endpoint is the OTLP/HTTP traces endpoint of the collector,
+	// http://localhost:4318/v1/traces if empty.
+	Endpoint string
+	// ServiceName is the service.name of the resource, "unknown_service"
+	// if empty.
+	ServiceName string
+	// Headers are added to the export requests, e.g. the authorization.
+	Headers map[string]string
+	// Client sends the export requests, a client with a 10s timeout if nil.
+	Client *http.Client
+	// BatchSize is the maximum number of spans per export request, 512 if 0.
+	BatchSize int
+	// QueueSize is the number of spans queued for export, 2048 if 0. The
+	// spans ended while the queue is full are dropped.
+	QueueSize int
+	// Interval is the delay between the export requests of the batches not
+	// full, 5s if 0.
+	Interval time.Duration
+	// OnError receives the export errors, the standard logger if nil.
+	OnError func(err error)
+}
+
+// OTLPExporter sends the spans to an OpenTelemetry collector with the
+// JSON encoding of OTLP/HTTP, refer to
+// https://opentelemetry.io/docs/specs/otlp/#otlphttp
+// The spans are queued and sent by batches in the background.
+type OTLPExporter struct {
+	opts    OTLPOptions
+	queue   chan *Span
+	stop    chan context.Context
+	stopped chan struct{}
+	closed  atomic.Bool
+	once    sync.Once
+	dropped int64
+}
+
+var errShutdown = errors.New("tracing: exporter shut down")
+var errQueueFull = errors.New("tracing: export queue full, spans dropped")
+
+// NewOTLPExporter creates an OTLPExporter and starts its background sender.
+// Shutdown must be called to send the queued spans before exiting.
+func NewOTLPExporter(opts OTLPOptions) *OTLPExporter {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = "unknown_service"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 2048
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(err error) { log.Print(err) }
+	}
+	e := &OTLPExporter{opts: opts, queue: make(chan *Span, opts.QueueSize),
+		stop: make(chan context.Context), stopped: make(chan struct{})}
+	go e.run()
+	return e
+}
+
+// Export implements Exporter. It queues the spans without blocking.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	if e.closed.Load() {
+		return errShutdown
+	}
+	for i, s := range spans {
+		select {
+		case e.queue <- s:
+		default:
+			atomic.AddInt64(&e.dropped, int64(len(spans)-i))
+			return errQueueFull
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of spans dropped because the queue was full.
+func (e *OTLPExporter) Dropped() int64 {
+	return atomic.LoadInt64(&e.dropped)
+}
+
+// Shutdown implements Exporter. It sends the queued spans within the
+// deadline of ctx, and returns ctx.Err() if they could not all be sent.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	var err error
+	e.once.Do(func() {
+		e.closed.Store(true)
+		select {
+		case e.stop <- ctx:
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+		select {
+		case <-e.stopped:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	})
+	return err
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, e.opts.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) > 0 {
+			if err := e.send(ctx, batch); err != nil {
+				e.opts.OnError(err)
+			}
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.opts.BatchSize {
+				flush(context.Background())
+			}
+		case <-ticker.C:
+			flush(context.Background())
+		case ctx := <-e.stop:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+					if len(batch) >= e.opts.BatchSize {
+						flush(ctx)
+					}
+				default:
+					flush(ctx)
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts the spans to the collector.
+func (e *OTLPExporter) send(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("tracing: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("tracing: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: export of %d spans failed: %v", len(spans), err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("tracing: export of %d spans failed: %s", len(spans), resp.Status)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding of the ExportTraceServiceRequest. The ids are hex
+// strings and the 64 bit integers are decimal strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	TraceState        string         `json:"traceState,omitempty"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    string   `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *OTLPExporter) request(spans []*Span) otlpRequest {
+	ss := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		code, msg := s.Status()
+		ss[i] = otlpSpan{
+			TraceID:           s.Context.TraceID.String(),
+			SpanID:            s.Context.SpanID.String(),
+			TraceState:        s.Context.State,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+			Status:            otlpStatus{code, msg},
+		}
+		if s.ParentID.IsValid() {
+			ss[i].ParentSpanID = s.ParentID.String()
+		}
+		for _, a := range s.Attributes() {
+			ss[i].Attributes = append(ss[i].Attributes, otlpKeyValue{a.Key, anyValue(a.Value)})
+		}
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{"service.name", anyValue(e.opts.ServiceName)}}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/dlmc/golight/decorator/tracing"},
+			Spans: ss}},
+	}}}
+}
+
+func anyValue(v interface{}) otlpAnyValue {
+	switch v := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		return otlpAnyValue{IntValue: strconv.Itoa(v)}
+	case int64:
+		return otlpAnyValue{IntValue: strconv.FormatInt(v, 10)}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	}
+	s := fmt.Sprint(v)
+	return otlpAnyValue{StringValue: &s}
+}
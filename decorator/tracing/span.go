@@ -0,0 +1,170 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+// SpanKind is the role of a span in the trace.
+type SpanKind int
+
+// The values are the ones of OTLP.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode is the status of a span.
+type StatusCode int
+
+// The values are the ones of OTLP.
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key / value of a span. The Value is a string, a bool, an
+// int, an int64 or a float64; the other types are exported as strings.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is an operation of the trace. Its methods are safe for concurrent
+// use and do nothing once the span has ended.
+type Span struct {
+	Name     string
+	Kind     SpanKind
+	Context  SpanContext
+	ParentID SpanID // zero for the root span of the trace
+	Start    time.Time
+
+	tracer *tracer
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []Attribute
+	status     StatusCode
+	message    string
+}
+
+var spanKey = ghttp.NewKey[*Span]("tracing.span")
+
+// SpanFrom returns the current span of the Ctx.
+func SpanFrom(c ghttp.Ctx) (*Span, bool) {
+	return spanKey.From(c)
+}
+
+// StartSpan starts a child of the current span of the Ctx, and returns the
+// Ctx with the child as its current span. The span must be ended.
+// Without a current span, the returned span is not recorded.
+// Usage example:
+/*
+	c, span := tracing.StartSpan(c, "load user")
+	defer span.End()
+	span.SetAttribute("user.id", id)
+	if err := db.QueryRowContext(c, q, id).Scan(&u); err != nil {
+		span.SetError(err)
+	}
+*/
+func StartSpan(c ghttp.Ctx, name string) (ghttp.Ctx, *Span) {
+	return startSpan(c, name, KindInternal)
+}
+
+func startSpan(c ghttp.Ctx, name string, kind SpanKind) (ghttp.Ctx, *Span) {
+	parent, ok := SpanFrom(c)
+	if !ok {
+		return c, &Span{Name: name, Kind: kind, Start: time.Now()}
+	}
+	s := parent.tracer.newSpan(name, kind, parent.Context)
+	return spanKey.With(c, s), s
+}
+
+// SetAttribute sets the attribute key, replacing its previous value.
+func (s *Span) SetAttribute(key string, val interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.end.IsZero() {
+		return
+	}
+	for i := range s.attributes {
+		if s.attributes[i].Key == key {
+			s.attributes[i].Value = val
+			return
+		}
+	}
+	s.attributes = append(s.attributes, Attribute{key, val})
+}
+
+// SetStatus sets the status of the span.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.end.IsZero() {
+		s.status, s.message = code, message
+	}
+}
+
+// SetError sets the error status with the message of err, if not nil.
+func (s *Span) SetError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End ends the span and exports it when the trace is sampled. The calls
+// after the first one do nothing.
+func (s *Span) End() {
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	if s.tracer != nil && s.Context.Sampled() {
+		s.tracer.exporter.Export(context.Background(), []*Span{s})
+	}
+}
+
+// EndTime returns the end time of the span, zero before End.
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// Attributes returns a copy of the attributes of the span.
+func (s *Span) Attributes() []Attribute {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attribute(nil), s.attributes...)
+}
+
+// Attribute returns the value of the attribute key.
+func (s *Span) Attribute(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.attributes {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Status returns the status of the span and its message.
+func (s *Span) Status() (StatusCode, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status, s.message
+}
@@ -0,0 +1,134 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// The headers of the W3C Trace Context, refer to
+// https://www.w3.org/TR/trace-context/
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// maxTracestate is the length above which the tracestate is dropped.
+const maxTracestate = 512
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the TraceID is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// String returns the lowercase hex of the TraceID.
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid reports whether the SpanID is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// String returns the lowercase hex of the SpanID.
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// FlagSampled is the trace flag of the sampled traces.
+const FlagSampled byte = 0x01
+
+// SpanContext is the part of a span propagated to the other services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+	State   string // the tracestate, passed through as is
+}
+
+// IsValid reports whether both ids are valid.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Sampled reports whether the trace is sampled.
+func (sc SpanContext) Sampled() bool { return sc.Flags&FlagSampled != 0 }
+
+// Traceparent returns the traceparent header of the SpanContext, e.g.
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (sc SpanContext) Traceparent() string {
+	b := make([]byte, 0, 55)
+	b = append(b, "00-"...)
+	b = hex.AppendEncode(b, sc.TraceID[:])
+	b = append(b, '-')
+	b = hex.AppendEncode(b, sc.SpanID[:])
+	b = append(b, '-')
+	b = hex.AppendEncode(b, []byte{sc.Flags})
+	return string(b)
+}
+
+// ParseTraceparent parses the traceparent header. The versions above 00
+// are parsed as 00, as the specification requires, so that the ids of the
+// newer clients are kept.
+func ParseTraceparent(s string) (SpanContext, bool) {
+	var sc SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, false
+	}
+	var version [1]byte
+	if !decodeHex(version[:], s[:2]) || version[0] == 0xff ||
+		(version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, false
+	}
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], s[3:35]) || !decodeHex(sc.SpanID[:], s[36:52]) ||
+		!decodeHex(flags[:], s[53:55]) || !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Flags = flags[0]
+	return sc, true
+}
+
+// decodeHex decodes the lowercase hex s into dst, which has the length of
+// the decoded s.
+func decodeHex(dst []byte, s string) bool {
+	if strings.ToLower(s) != s {
+		return false
+	}
+	n, err := hex.Decode(dst, []byte(s))
+	return err == nil && n == len(dst)
+}
+
+// validTracestate reports whether the tracestate may be passed on: not
+// longer than 512 bytes and made of printable ASCII characters.
+func validTracestate(s string) bool {
+	if len(s) > maxTracestate {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTraceID returns a random TraceID.
+func NewTraceID() TraceID {
+	var t TraceID
+	for !t.IsValid() {
+		rand.Read(t[:])
+	}
+	return t
+}
+
+// NewSpanID returns a random SpanID.
+func NewSpanID() SpanID {
+	var s SpanID
+	for !s.IsValid() {
+		rand.Read(s[:])
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing_test
+
+import (
+	"testing"
+
+	"github.com/dlmc/golight/decorator/tracing"
+)
+
+//Test cases for the traceparent header
+func TestParseTraceparent(t *testing.T) {
+	testCases := []struct {
+		header string
+		ok     bool
+		trace  string
+		span   string
+		sample bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true,
+			"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", true,
+			"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true,
+			"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", false, "", "", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, "", "", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, "", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", false, "", "", false},
+		{"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01", false, "", "", false},
+		{"", false, "", "", false},
+	}
+	for i, tc := range testCases {
+		sc, ok := tracing.ParseTraceparent(tc.header)
+		if ok != tc.ok {
+			t.Errorf("Test case %d failed, got: %v", i, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if sc.TraceID.String() != tc.trace || sc.SpanID.String() != tc.span || sc.Sampled() != tc.sample {
+			t.Errorf("Test case %d failed, got: %+v", i, sc)
+		}
+		want := "00-" + tc.trace + "-" + tc.span + "-00"
+		if tc.sample {
+			want = want[:len(want)-1] + "1"
+		}
+		if sc.Traceparent() != want {
+			t.Errorf("Test case %d failed, got: %s", i, sc.Traceparent())
+		}
+	}
+}
+
+func TestNewIDs(t *testing.T) {
+	if a, b := tracing.NewTraceID(), tracing.NewTraceID(); !a.IsValid() || a == b {
+		t.Errorf("NewTraceID failed, got: %s %s", a, b)
+	}
+	if a, b := tracing.NewSpanID(), tracing.NewSpanID(); !a.IsValid() || a == b || len(a.String()) != 16 {
+		t.Errorf("NewSpanID failed, got: %s %s", a, b)
+	}
+}
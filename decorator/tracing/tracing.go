@@ -0,0 +1,199 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracing propagates the W3C Trace Context of the requests and
+// records their spans, exported through a pluggable Exporter, e.g. to an
+// OpenTelemetry collector with OTLPExporter.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/ghttp"
+)
+
+// Options configures CreateDecorWith.
+type Options struct {
+	Exporter Exporter
+	// Sampler decides whether the new traces are sampled, all of them if
+	// nil. The traces started upstream keep their sampled flag.
+	Sampler func(h *ghttp.Http) bool
+	// TraceField and SpanField are the fields of the ids in h.Log,
+	// "trace_id" and "span_id" if empty.
+	TraceField string
+	SpanField  string
+}
+
+// tracer creates the spans of a decorator and exports them.
+type tracer struct {
+	exporter Exporter
+}
+
+func (t *tracer) newSpan(name string, kind SpanKind, parent SpanContext) *Span {
+	return &Span{Name: name, Kind: kind, ParentID: parent.SpanID, Start: time.Now(), tracer: t,
+		Context: SpanContext{TraceID: parent.TraceID, SpanID: NewSpanID(), Flags: parent.Flags, State: parent.State}}
+}
+
+// CreateDecor creates a decorator that records the server span of each
+// request into exp, refer to CreateDecorWith for details.
+func CreateDecor(exp Exporter) decorator.Decorator {
+	return CreateDecorWith(Options{Exporter: exp})
+}
+
+// CreateDecorWith creates a decorator that continues the trace of the
+// traceparent and tracestate headers, or starts a new one, with a server
+// span per request. The span is the current span of the Ctx, so that the
+// handlers can start child spans with StartSpan, and the trace and span
+// ids are added to the fields of h.Log.
+// The span is named after the method and the route pattern, e.g.
+// "GET /users/{id}", and carries the http.request.method, http.route,
+// url.path and http.response.status_code attributes. A 5xx status or a
+// panic of the handler sets the error status of the span.
+// Usage example:
+/*
+	exp := tracing.NewOTLPExporter(tracing.OTLPOptions{ServiceName: "users"})
+	defer exp.Shutdown(context.Background())
+
+	h := decorator.Decorate(hdl, respond.CreateDecor(), tracing.CreateDecor(exp),
+		recovery.CreateDecor(), logging.CreateDecor(lc))
+*/
+// Use Transport or Inject to propagate the trace to the downstream services.
+// It panics without an Exporter.
+func CreateDecorWith(opts Options) decorator.Decorator {
+	if opts.Exporter == nil {
+		panic("tracing: nil Exporter")
+	}
+	if opts.TraceField == "" {
+		opts.TraceField = "trace_id"
+	}
+	if opts.SpanField == "" {
+		opts.SpanField = "span_id"
+	}
+	t := &tracer{exporter: opts.Exporter}
+
+	return decorator.Named("tracing", func(next ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			parent, ok := ParseTraceparent(h.R.Header.Get(TraceparentHeader))
+			if ok {
+				if ts := h.R.Header.Get(TracestateHeader); validTracestate(ts) {
+					parent.State = ts
+				}
+			} else {
+				parent = SpanContext{TraceID: NewTraceID()}
+				if opts.Sampler == nil || opts.Sampler(h) {
+					parent.Flags = FlagSampled
+				}
+			}
+
+			name := h.R.Method
+			if h.Pattern != "" {
+				name += " " + h.Pattern
+			}
+			s := t.newSpan(name, KindServer, parent)
+			s.SetAttribute("http.request.method", h.R.Method)
+			if h.Pattern != "" {
+				s.SetAttribute("http.route", h.Pattern)
+			}
+			s.SetAttribute("url.path", h.R.URL.Path)
+
+			if c == nil {
+				c = context.Background()
+			}
+			c = spanKey.With(c, s)
+			c = logging.WithStr(c, h, opts.TraceField, s.Context.TraceID.String())
+			c = logging.WithStr(c, h, opts.SpanField, s.Context.SpanID.String())
+
+			w := ghttp.WrapWriter(h.W)
+			orig := h.W
+			h.W = w
+			defer func() {
+				h.W = orig
+				status := w.Status()
+				if status == 0 {
+					//net/http sends 200 for the handlers that write nothing
+					status = http.StatusOK
+				}
+				if v := recover(); v != nil {
+					s.SetStatus(StatusError, fmt.Sprint("panic: ", v))
+					s.End()
+					panic(v)
+				}
+				s.SetAttribute("http.response.status_code", status)
+				if status >= 500 {
+					if h.Err != nil {
+						s.SetError(h.Err)
+					} else {
+						s.SetStatus(StatusError, http.StatusText(status))
+					}
+				}
+				s.End()
+			}()
+			return next.ServeHTTPWithCtx(c, h)
+		})
+	})
+}
+
+// Inject sets the traceparent and tracestate headers of the current span
+// of the Ctx, if any, e.g. into an outbound request.
+func Inject(c ghttp.Ctx, hdr http.Header) {
+	s, ok := SpanFrom(c)
+	if !ok || !s.Context.IsValid() {
+		return
+	}
+	hdr.Set(TraceparentHeader, s.Context.Traceparent())
+	if s.Context.State != "" {
+		hdr.Set(TracestateHeader, s.Context.State)
+	} else {
+		hdr.Del(TracestateHeader)
+	}
+}
+
+// Transport is an http.RoundTripper that records a client span of each
+// outbound request, as a child of the current span of the request context,
+// and propagates the trace to the downstream services. The span ends once
+// the response headers are received.
+// Usage example:
+/*
+	client := &http.Client{Transport: &tracing.Transport{}}
+	req, _ := http.NewRequestWithContext(c, "GET", "http://inventory/items", nil)
+	resp, err := client.Do(req)
+*/
+type Transport struct {
+	Base http.RoundTripper // http.DefaultTransport if nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c, s := startSpan(req.Context(), req.Method, KindClient)
+	if s.tracer == nil {
+		return base.RoundTrip(req)
+	}
+	s.SetAttribute("http.request.method", req.Method)
+	s.SetAttribute("server.address", req.URL.Host)
+	s.SetAttribute("url.full", req.URL.Redacted())
+
+	//RoundTrippers must not modify the request
+	req = req.Clone(c)
+	Inject(c, req.Header)
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		s.SetError(err)
+	} else {
+		s.SetAttribute("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			s.SetStatus(StatusError, http.StatusText(resp.StatusCode))
+		}
+	}
+	s.End()
+	return resp, err
+}
@@ -0,0 +1,286 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/decorator/tracing"
+	"github.com/dlmc/golight/ghttp"
+)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+//Test cases for the server span and its child spans
+func TestTracing(t *testing.T) {
+	exp := tracing.NewMemoryExporter()
+	out := &bytes.Buffer{}
+	var child *tracing.Span
+	hdl := ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		c2, s := tracing.StartSpan(c, "load user")
+		s.SetAttribute("user.id", h.Params.Get("id"))
+		s.SetError(errors.New("not found"))
+		if cur, _ := tracing.SpanFrom(c2); cur != s {
+			t.Errorf("StartSpan did not set the current span")
+		}
+		s.End()
+		child = s
+		h.Log.Logger().Info().Msg("handled")
+		h.W.WriteHeader(http.StatusNotFound)
+		return c
+	})
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET": decorator.Decorate(hdl, tracing.CreateDecor(exp),
+		logging.CreateDecor(logging.NewContext(out)))})
+
+	testCases := []struct {
+		traceparent, tracestate string
+		continued, sampled      bool
+	}{
+		{parent, "vendor=abc", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "", true, false},
+		{"bogus", "", false, true},
+		{"", "", false, true},
+	}
+	for i, tc := range testCases {
+		exp.Reset()
+		out.Reset()
+		r := httptest.NewRequest("GET", "/users/7", nil)
+		if tc.traceparent != "" {
+			r.Header.Set("traceparent", tc.traceparent)
+			r.Header.Set("tracestate", tc.tracestate)
+		}
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := exp.Spans()
+		if !tc.sampled {
+			if len(spans) != 0 {
+				t.Errorf("Test case %d exported the spans of a trace not sampled", i)
+			}
+			continue
+		}
+		if len(spans) != 2 || spans[0] != child {
+			t.Fatalf("Test case %d failed, got: %d spans", i, len(spans))
+		}
+		srv := spans[1]
+		if srv.Name != "GET /users/{id}" || srv.Kind != tracing.KindServer || srv.Context.State != tc.tracestate {
+			t.Errorf("Test case %d failed, got: %+v", i, srv)
+		}
+		if tc.continued && (srv.Context.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+			srv.ParentID.String() != "00f067aa0ba902b7") {
+			t.Errorf("Test case %d did not continue the trace, got: %+v", i, srv.Context)
+		}
+		if !tc.continued && srv.ParentID.IsValid() {
+			t.Errorf("Test case %d has a parent, got: %s", i, srv.ParentID)
+		}
+		if v, _ := srv.Attribute("http.route"); v != "/users/{id}" {
+			t.Errorf("Test case %d http.route failed, got: %v", i, v)
+		}
+		if v, _ := srv.Attribute("http.response.status_code"); v != 404 {
+			t.Errorf("Test case %d status failed, got: %v", i, v)
+		}
+		if code, _ := srv.Status(); code != tracing.StatusUnset {
+			t.Errorf("Test case %d set the error status of a 4xx, got: %v", i, code)
+		}
+		if child.Context.TraceID != srv.Context.TraceID || child.ParentID != srv.Context.SpanID ||
+			child.Kind != tracing.KindInternal || child.EndTime().Before(child.Start) {
+			t.Errorf("Test case %d child failed, got: %+v", i, child)
+		}
+		if code, msg := child.Status(); code != tracing.StatusError || msg != "not found" {
+			t.Errorf("Test case %d child status failed, got: %v %s", i, code, msg)
+		}
+		logs := out.String()
+		if !strings.Contains(logs, `"trace_id":"`+srv.Context.TraceID.String()+`"`) ||
+			!strings.Contains(logs, `"span_id":"`+srv.Context.SpanID.String()+`"`) {
+			t.Errorf("Test case %d log fields failed, got: %s", i, logs)
+		}
+	}
+}
+
+func TestTracingErrors(t *testing.T) {
+	exp := tracing.NewMemoryExporter()
+	decor := tracing.CreateDecorWith(tracing.Options{Exporter: exp, Sampler: func(h *ghttp.Http) bool {
+		return h.R.URL.Path != "/skip"
+	}})
+	testCases := []struct {
+		path    string
+		hdl     ghttp.HandlerFunc
+		message string
+	}{
+		{"/err", func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.Err = errors.New("db down")
+			h.W.WriteHeader(500)
+			return c
+		}, "db down"},
+		{"/503", func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.WriteHeader(503)
+			return c
+		}, "Service Unavailable"},
+		{"/panic", func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			panic("boom")
+		}, "panic: boom"},
+		{"/skip", func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.WriteHeader(500)
+			return c
+		}, ""},
+	}
+	for i, tc := range testCases {
+		exp.Reset()
+		func() {
+			defer func() {
+				if v := recover(); (v != nil) != (tc.path == "/panic") {
+					t.Errorf("Test case %d panic failed, got: %v", i, v)
+				}
+			}()
+			ghttp.Router{"GET": decorator.Decorate(tc.hdl, decor)}.ServeHTTP(httptest.NewRecorder(),
+				httptest.NewRequest("GET", tc.path, nil))
+		}()
+		spans := exp.Spans()
+		if tc.message == "" {
+			if len(spans) != 0 {
+				t.Errorf("Test case %d sampler failed, got: %d spans", i, len(spans))
+			}
+			continue
+		}
+		if len(spans) != 1 || spans[0].Name != "GET" {
+			t.Fatalf("Test case %d failed, got: %d spans", i, len(spans))
+		}
+		if code, msg := spans[0].Status(); code != tracing.StatusError || msg != tc.message {
+			t.Errorf("Test case %d failed, got: %v %s", i, code, msg)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(502)
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: &tracing.Transport{}}
+
+	exp := tracing.NewMemoryExporter()
+	hdl := ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		req, _ := http.NewRequestWithContext(c, "GET", downstream.URL+"/items", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return c
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", parent)
+	r.Header.Set("tracestate", "vendor=abc")
+	ghttp.Router{"GET": decorator.Decorate(hdl, tracing.CreateDecor(exp))}.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exp.Spans()
+	if len(spans) != 2 || spans[0].Kind != tracing.KindClient || spans[0].ParentID != spans[1].Context.SpanID {
+		t.Fatalf("Transport failed, got: %d spans", len(spans))
+	}
+	if got.Get("traceparent") != spans[0].Context.Traceparent() || got.Get("tracestate") != "vendor=abc" {
+		t.Errorf("Transport propagation failed, got: %v", got)
+	}
+	if code, _ := spans[0].Status(); code != tracing.StatusError {
+		t.Errorf("Transport status failed, got: %v", code)
+	}
+
+	//without a current span the request is sent as is
+	got = nil
+	resp, err := client.Get(downstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("traceparent") != "" {
+		t.Errorf("Transport without span failed, got: %v", got)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var m map[string]interface{}
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" ||
+			r.Header.Get("Authorization") != "Bearer t" || json.Unmarshal(b, &m) != nil {
+			w.WriteHeader(400)
+			return
+		}
+		mu.Lock()
+		bodies = append(bodies, m)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	var errs []error
+	exp := tracing.NewOTLPExporter(tracing.OTLPOptions{Endpoint: collector.URL + "/v1/traces",
+		ServiceName: "users", Headers: map[string]string{"Authorization": "Bearer t"},
+		BatchSize: 2, Interval: time.Hour, OnError: func(err error) { errs = append(errs, err) }})
+	hdl := ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		_, s := tracing.StartSpan(c, "query")
+		s.SetAttribute("db.rows", 3)
+		s.SetAttribute("db.cached", true)
+		s.SetAttribute("db.ratio", 0.5)
+		s.End()
+		return c
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", parent)
+	h := decorator.Decorate(hdl, tracing.CreateDecor(exp))
+	ghttp.Router{"GET": h}.ServeHTTP(httptest.NewRecorder(), r)
+	ghttp.Router{"GET": h}.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exp.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if exp.Export(ctx, nil) == nil {
+		t.Errorf("Export after Shutdown did not fail")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 0 || len(bodies) != 2 {
+		t.Fatalf("OTLP export failed, got: %d requests %v", len(bodies), errs)
+	}
+
+	rs := bodies[0]["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	attr := rs["resource"].(map[string]interface{})["attributes"].([]interface{})[0]
+	if b, _ := json.Marshal(attr); string(b) != `{"key":"service.name","value":{"stringValue":"users"}}` {
+		t.Errorf("OTLP resource failed, got: %s", b)
+	}
+	spans := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	if len(spans) != 2 {
+		t.Fatalf("OTLP batch failed, got: %d spans", len(spans))
+	}
+	query, server := spans[0].(map[string]interface{}), spans[1].(map[string]interface{})
+	if query["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || query["parentSpanId"] != server["spanId"] ||
+		server["parentSpanId"] != "00f067aa0ba902b7" || query["kind"] != 1.0 || server["kind"] != 2.0 {
+		t.Errorf("OTLP spans failed, got: %v %v", query, server)
+	}
+	if _, ok := query["startTimeUnixNano"].(string); !ok {
+		t.Errorf("OTLP times are not strings, got: %v", query)
+	}
+	b, _ := json.Marshal(query["attributes"])
+	if string(b) != `[{"key":"db.rows","value":{"intValue":"3"}},{"key":"db.cached","value":{"boolValue":true}},`+
+		`{"key":"db.ratio","value":{"doubleValue":0.5}}]` {
+		t.Errorf("OTLP attributes failed, got: %s", b)
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// BindError is the error returned by Bind when the request can not be
+// bound. Its status code is 400, 413 for a body over the size limit and
+// 415 for an unsupported content type.
+type BindError struct {
+	Field  string // the bound field name, empty for body errors
+	Status int
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return "bind: " + e.Err.Error()
+	}
+	return "bind " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error { return e.Err }
+
+// StatusCode returns the http status code of the error.
+func (e *BindError) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusBadRequest
+	}
+	return e.Status
+}
+
+// BindOptions configures Http.BindWith.
+type BindOptions struct {
+	MaxBodySize           int64 // max size of the body in bytes, DefaultBindOptions' if 0, no limit if < 0
+	MaxMemory             int64 // max multipart form size kept in memory, DefaultBindOptions' if <= 0
+	DisallowUnknownFields bool  // reject the JSON objects with unknown fields
+}
+
+// DefaultBindOptions is used by Http.Bind.
+var DefaultBindOptions = BindOptions{
+	MaxBodySize: 1 << 20,
+	MaxMemory:   1 << 20,
+}
+
+// Bind binds the request into the struct pointed to by v with the
+// DefaultBindOptions. Refer to BindWith for details.
+func (h *Http) Bind(v interface{}) error {
+	return h.BindWith(v, DefaultBindOptions)
+}
+
+// BindWith binds the request into the struct pointed to by v.
+// The body is decoded first based on its Content-Type:
+//	application/json                    with encoding/json, the json tags apply
+//	application/x-www-form-urlencoded   into the fields tagged `form:"name"`
+//	multipart/form-data                 into the fields tagged `form:"name"`,
+//	                                    *multipart.FileHeader fields get the files
+// Then the fields tagged `query:"name"` are filled from h.Query and the
+// fields tagged `path:"name"` from h.Params.
+// Usage example:
+/*
+	type orderReq struct {
+		UserID  int      `path:"id"`
+		Expand  []string `query:"expand"`
+		SKU     string   `json:"sku" form:"sku"`
+		Count   int      `json:"count" form:"count"`
+	}
+
+	var req orderReq
+	if err := h.Bind(&req); err != nil {
+		h.Err = err  //respond.CreateDecor responds with 400
+		return c
+	}
+*/
+// The errors due to the request are returned as *BindError.
+func (h *Http) BindWith(v interface{}, opts BindOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ghttp: Bind requires a non-nil pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+
+	if err := h.bindBody(v, rv, opts); err != nil {
+		return err
+	}
+	if err := bindValues(rv, "query", func(name string) ([]string, bool) {
+		vs, ok := h.Query[name]
+		return vs, ok
+	}); err != nil {
+		return err
+	}
+	return bindValues(rv, "path", func(name string) ([]string, bool) {
+		pv, ok := h.Params.Lookup(name)
+		return []string{pv}, ok
+	})
+}
+
+func (h *Http) bindBody(v interface{}, rv reflect.Value, opts BindOptions) error {
+	r := h.R
+	if r == nil || r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+	max := opts.MaxBodySize
+	if max == 0 {
+		max = DefaultBindOptions.MaxBodySize
+	}
+	if max > 0 {
+		if r.ContentLength > max {
+			return &BindError{Status: http.StatusRequestEntityTooLarge,
+				Err: errors.New("request body too large")}
+		}
+		r.Body = http.MaxBytesReader(h.W, r.Body, max)
+	}
+
+	ct := r.Header.Get("Content-Type")
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil && ct != "" {
+		return &BindError{Status: http.StatusUnsupportedMediaType, Err: err}
+	}
+
+	switch mt {
+	case "application/json":
+		dec := json.NewDecoder(r.Body)
+		if opts.DisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		if err := dec.Decode(v); err != nil && err != io.EOF {
+			return bodyError(err)
+		}
+		return nil
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return bodyError(err)
+		}
+		return bindValues(rv, "form", func(name string) ([]string, bool) {
+			vs, ok := r.PostForm[name]
+			return vs, ok
+		})
+	case "multipart/form-data":
+		mem := opts.MaxMemory
+		if mem <= 0 {
+			mem = DefaultBindOptions.MaxMemory
+		}
+		if err := r.ParseMultipartForm(mem); err != nil {
+			return bodyError(err)
+		}
+		if err := bindFiles(rv, r.MultipartForm.File); err != nil {
+			return err
+		}
+		return bindValues(rv, "form", func(name string) ([]string, bool) {
+			vs, ok := r.MultipartForm.Value[name]
+			return vs, ok
+		})
+	}
+	return &BindError{Status: http.StatusUnsupportedMediaType,
+		Err: fmt.Errorf("unsupported content type %q", ct)}
+}
+
+func bodyError(err error) error {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return &BindError{Status: http.StatusRequestEntityTooLarge, Err: err}
+	}
+	return &BindError{Err: err}
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	durationType    = reflect.TypeOf(time.Duration(0))
+)
+
+// fields calls fn for each field of the struct tagged with tag, including
+// the fields of the embedded structs.
+func fields(rv reflect.Value, tag string, fn func(fv reflect.Value, name string) error) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		name := sf.Tag.Get(tag)
+		if name == "" || name == "-" {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				if err := fields(fv, tag, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := fn(fv, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bindFiles(rv reflect.Value, files map[string][]*multipart.FileHeader) error {
+	return fields(rv, "form", func(fv reflect.Value, name string) error {
+		fhs := files[name]
+		switch {
+		case len(fhs) == 0:
+		case fv.Type() == fileHeaderType:
+			fv.Set(reflect.ValueOf(fhs[0]))
+		case fv.Type() == fileHeadersType:
+			fv.Set(reflect.ValueOf(fhs))
+		}
+		return nil
+	})
+}
+
+func bindValues(rv reflect.Value, tag string, get func(name string) ([]string, bool)) error {
+	return fields(rv, tag, func(fv reflect.Value, name string) error {
+		if fv.Type() == fileHeaderType || fv.Type() == fileHeadersType {
+			return nil
+		}
+		vs, ok := get(name)
+		if !ok || len(vs) == 0 {
+			return nil
+		}
+		if err := setValues(fv, vs); err != nil {
+			return &BindError{Field: name, Err: err}
+		}
+		return nil
+	})
+}
+
+// setValues sets the field from the string values, slices get all values.
+func setValues(fv reflect.Value, vs []string) error {
+	if _, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); !ok && fv.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+		for i, v := range vs {
+			if err := setValue(s.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(s)
+		return nil
+	}
+	return setValue(fv, vs[0])
+}
+
+func setValue(fv reflect.Value, v string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValue(fv.Elem(), v)
+	}
+	if tu, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(v))
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(v)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(v, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(v, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(v, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+type bindBase struct {
+	Trace string `query:"trace"`
+}
+
+type bindReq struct {
+	bindBase
+	ID      int                   `path:"id"`
+	Expand  []string              `query:"expand"`
+	Limit   *uint8                `query:"limit"`
+	Timeout time.Duration         `query:"timeout"`
+	SKU     string                `json:"sku" form:"sku"`
+	Count   int                   `json:"count" form:"count"`
+	Price   float64               `json:"price" form:"price"`
+	Gift    bool                  `json:"gift" form:"gift"`
+	File    *multipart.FileHeader `json:"-" form:"file"`
+}
+
+//Bind the request into a bindReq through the Mux
+func tBind(t *testing.T, r *http.Request, opts ghttp.BindOptions) (bindReq, error) {
+	var req bindReq
+	var err error
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}/orders", ghttp.Router{"POST": ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		err = h.BindWith(&req, opts)
+		return c
+	})})
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	return req, err
+}
+
+func newBindRequest(body, ct string) *http.Request {
+	r := httptest.NewRequest("POST", "/users/42/orders?expand=a&expand=b&limit=5&timeout=2s&trace=t1", strings.NewReader(body))
+	if ct != "" {
+		r.Header.Set("Content-Type", ct)
+	}
+	return r
+}
+
+//Test cases for binding the different content types
+func TestBind(t *testing.T) {
+	limit := uint8(5)
+	want := bindReq{bindBase: bindBase{"t1"}, ID: 42, Expand: []string{"a", "b"}, Limit: &limit,
+		Timeout: 2 * time.Second, SKU: "x1", Count: 3, Price: 1.5, Gift: true}
+
+	t.Run("JSON", func(t *testing.T) {
+		r := newBindRequest(`{"sku":"x1","count":3,"price":1.5,"gift":true}`, "application/json; charset=utf-8")
+		got, err := tBind(t, r, ghttp.DefaultBindOptions)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Bind JSON failed, got: %+v %v, want: %+v", got, err, want)
+		}
+	})
+	t.Run("Form", func(t *testing.T) {
+		r := newBindRequest(`sku=x1&count=3&price=1.5&gift=true`, "application/x-www-form-urlencoded")
+		got, err := tBind(t, r, ghttp.DefaultBindOptions)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Bind form failed, got: %+v %v, want: %+v", got, err, want)
+		}
+	})
+	t.Run("Multipart", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		mw.WriteField("sku", "x1")
+		mw.WriteField("count", "3")
+		mw.WriteField("price", "1.5")
+		mw.WriteField("gift", "true")
+		fw, _ := mw.CreateFormFile("file", "a.txt")
+		fw.Write([]byte("content"))
+		mw.Close()
+
+		got, err := tBind(t, newBindRequest(body.String(), mw.FormDataContentType()), ghttp.DefaultBindOptions)
+		if err != nil || got.File == nil || got.File.Filename != "a.txt" {
+			t.Fatalf("Bind multipart failed, got: %+v %v", got, err)
+		}
+		got.File = nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Bind multipart failed, got: %+v, want: %+v", got, want)
+		}
+	})
+	t.Run("ZeroOptions", func(t *testing.T) {
+		r := newBindRequest(`{"sku":"x1","count":3,"price":1.5,"gift":true}`, "application/json")
+		got, err := tBind(t, r, ghttp.BindOptions{DisallowUnknownFields: true})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Bind with zero options failed, got: %+v %v, want: %+v", got, err, want)
+		}
+	})
+	t.Run("NoBody", func(t *testing.T) {
+		got, err := tBind(t, newBindRequest("", ""), ghttp.DefaultBindOptions)
+		if err != nil || got.ID != 42 || got.SKU != "" {
+			t.Errorf("Bind without body failed, got: %+v %v", got, err)
+		}
+	})
+}
+
+//Test cases for the bind errors
+func TestBindErrors(t *testing.T) {
+	strict := ghttp.DefaultBindOptions
+	strict.DisallowUnknownFields = true
+	small := ghttp.DefaultBindOptions
+	small.MaxBodySize = 8
+
+	tests := []struct {
+		name   string
+		r      *http.Request
+		opts   ghttp.BindOptions
+		field  string
+		status int
+	}{
+		{"BadJSON", newBindRequest(`{"sku":`, "application/json"), ghttp.DefaultBindOptions, "", 400},
+		{"UnknownField", newBindRequest(`{"x":1}`, "application/json"), strict, "", 400},
+		{"TooLarge", newBindRequest(`{"sku":"0123456789"}`, "application/json"), small, "", 413},
+		{"TooLargeDefault", newBindRequest(`{"sku":"`+strings.Repeat("x", 1<<20)+`"}`, "application/json"), ghttp.BindOptions{}, "", 413},
+		{"MediaType", newBindRequest(`<a/>`, "text/xml"), ghttp.DefaultBindOptions, "", 415},
+		{"BadForm", newBindRequest(`count=x`, "application/x-www-form-urlencoded"), ghttp.DefaultBindOptions, "count", 400},
+		{"BadQuery", httptest.NewRequest("POST", "/users/42/orders?limit=300", nil), ghttp.DefaultBindOptions, "limit", 400},
+		{"BadPath", httptest.NewRequest("POST", "/users/x/orders", nil), ghttp.DefaultBindOptions, "id", 400},
+	}
+	for _, tt := range tests {
+		_, err := tBind(t, tt.r, tt.opts)
+		var be *ghttp.BindError
+		if !errors.As(err, &be) {
+			t.Errorf("%s failed, got: %v, want a BindError", tt.name, err)
+			continue
+		}
+		if be.Field != tt.field || be.StatusCode() != tt.status {
+			t.Errorf("%s failed, got: %s %d, want: %s %d", tt.name, be.Field, be.StatusCode(), tt.field, tt.status)
+		}
+	}
+
+	var v int
+	if err := (&ghttp.Http{}).Bind(&v); err == nil {
+		t.Errorf("Bind into an int did not fail")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StatusError is an error that carries the http status code to respond
+// with. The respond decorator turns the StatusError set in Http.Err into
+// a Response with that code.
+type StatusError interface {
+	error
+	StatusCode() int
+}
+
+// DataError is an error that carries the details to report in the
+// Response Data, e.g. the field errors of validate.Errors.
+type DataError interface {
+	error
+	ResponseData() interface{}
+}
+
+// Problem is an RFC 7807 problem detail. The respond decorator renders a
+// Problem set in Http.Err as application/problem+json.
+// Usage example:
+/*
+	h.Err = &ghttp.Problem{
+		Type:   "https://example.com/probs/out-of-credit",
+		Status: http.StatusForbidden,
+		Detail: "Your current balance is 30, but that costs 50.",
+		Extensions: map[string]interface{}{"balance": 30},
+	}
+*/
+type Problem struct {
+	Type       string // URI reference of the problem type, about:blank if empty
+	Title      string // short summary, the status text if empty
+	Status     int    // http status code, 500 if zero
+	Detail     string // explanation specific to this occurrence
+	Instance   string // URI reference of this occurrence
+	Extensions map[string]interface{}
+}
+
+// NewProblem creates a Problem with the status and detail.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Status: status, Detail: detail}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.title()
+	}
+	return p.title() + ": " + p.Detail
+}
+
+// StatusCode returns the status of the problem, 500 if not set.
+func (p *Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+func (p *Problem) title() string {
+	if p.Title == "" {
+		return http.StatusText(p.StatusCode())
+	}
+	return p.Title
+}
+
+// MarshalJSON encodes the problem members with the extension members at
+// the same level, as defined by RFC 7807.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	m["title"] = p.title()
+	m["status"] = p.StatusCode()
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// ErrHandlerFunc is a HandlerFunc that returns an error. The error
+// returned is set in Http.Err for the respond decorator to report it.
+// Usage example:
+/*
+	var getUser = ghttp.ErrHandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) (ghttp.Ctx, error) {
+		u, ok := users[h.Params.Get("id")]
+		if !ok {
+			return c, ghttp.NewProblem(http.StatusNotFound, "no such user")
+		}
+		h.Resp.Data = u
+		return c, nil
+	})
+*/
+type ErrHandlerFunc func(Ctx, *Http) (Ctx, error)
+
+func (hf ErrHandlerFunc) ServeHTTPWithCtx(c Ctx, h *Http) Ctx {
+	c, err := hf(c, h)
+	if err != nil {
+		h.Err = err
+	}
+	return c
+}
@@ -10,7 +10,8 @@ import (
 	"context"
 	"sort"
 	"strings"
-	log "github.com/rs/zerolog"
+	"sync/atomic"
+	"github.com/dlmc/golight/logger"
 )
 
 
@@ -25,17 +26,22 @@ type Http struct {
 	Query url.Values
 	W http.ResponseWriter
 	R *http.Request
-	Log log.Context			//nil - use logging.Decor to assign the logger
+	Params Params			//path params captured by the Mux
+	Pattern string			//the route pattern matched by the Mux, e.g. /users/{id}
+	Err error				//the error respond.CreateDecor reports, e.g. a BindError
+	Log logger.Context		//nil - use logging.Decor to assign the logger
 }	
 
 // Internal int key
-var ctxKeyIndex int = 0
+var ctxKeyIndex int64 = 0
 
 // GetNextCtxKey returns next available integer key for the Ctx
+//
+// Deprecated: the int keys may collide with the int keys of other packages,
+// use NewKey instead.
 func GetNextCtxKey() int {
 	//Do not expect the integer to wrap around ever happen
-	ctxKeyIndex++
-	return ctxKeyIndex
+	return int(atomic.AddInt64(&ctxKeyIndex, 1))
 }
 
 
@@ -53,7 +59,13 @@ func (hf HandlerFunc) ServeHTTPWithCtx(c Ctx, h *Http) Ctx {
     return hf(c, h)
 }
 
+// ChildCtx returns a child of the parent Ctx that carries the key / value.
+// A nil parent is replaced by context.Background().
+// Prefer Key.With, which is type safe, to add values into the Ctx.
 func ChildCtx(parent Ctx, key, val interface{}) Ctx {
+	if parent == nil {
+		parent = context.Background()
+	}
 	return context.WithValue(parent, key, val)
 }
 
@@ -99,18 +111,22 @@ func main() {
 type Router map[string]Handler
 
 
+// ServeHTTP dispatches the request to the Handler of its method.
+// The root Ctx of the chain is r.Context(), so it is canceled when the
+// client goes away and carries the values of the http.Handler middlewares.
 func (rt Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.serve(w, r, "", nil)
+}
+
+// serve dispatches the request on its method with the route pattern and
+// the path params matched by the Mux.
+func (rt Router) serve(w http.ResponseWriter, r *http.Request, pattern string, ps Params) {
 	if h:=rt[r.Method]; h != nil {
-		hp := &Http{W:w, R:r, Query:r.URL.Query()}
-		h.ServeHTTPWithCtx(context.Background(), hp)
+		hp := &Http{W:w, R:r, Query:r.URL.Query(), Params:ps, Pattern:pattern}
+		h.ServeHTTPWithCtx(r.Context(), hp)
 	} else {
 		//http.Error(w, http.StatusText(501), 501)
-		allow := []string{}
-		for k := range rt {
-			allow = append(allow, k)
-		}
-		sort.Strings(allow)
-		w.Header().Set("Allow", strings.Join(allow, ", "))
+		w.Header().Set("Allow", strings.Join(rt.Methods(), ", "))
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 		} else {
@@ -120,6 +136,16 @@ func (rt Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Methods returns the sorted methods of the Router.
+func (rt Router) Methods() []string {
+	methods := make([]string, 0, len(rt))
+	for k := range rt {
+		methods = append(methods, k)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
 /*
 type valueCtx struct {
 	Context
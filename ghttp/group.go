@@ -0,0 +1,146 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Decorating is implemented by the types that decorate a Handler,
+// e.g. decorator.DecoratorChain.
+type Decorating interface {
+	Decorate(Handler) Handler
+}
+
+// Group registers routes on a Mux under a common prefix and decorates
+// their handlers with the decorators of the group and of all its parents.
+// The decorators of a parent group are called before the ones of its
+// sub groups, the same way Decorate calls the last decorator first:
+/*
+	mux := ghttp.NewMux()
+	api := mux.Group("/v1", decorator.Chain(respond.CreateDecor(), lg))
+	api.Handle("/health", ghttp.Router{"GET":health})
+	admin := api.Group("/admin", decorator.Chain(auth))
+	admin.Handle("/users/{id}", ghttp.Router{"DELETE":deleteUser})
+	//same as
+	// mux.Handle("/v1/health", decorator.DecorateRouter(ghttp.Router{"GET":health}, respond, lg))
+	// mux.Handle("/v1/admin/users/{id}", decorator.DecorateRouter(ghttp.Router{"DELETE":deleteUser}, auth, respond, lg))
+	before lg
+	before respond
+	before auth
+	deleteUser
+	after auth
+	after respond
+	after lg
+*/
+// The Routers passed in are left untouched, the decorated handlers are
+// stored in the Mux only.
+type Group struct {
+	mux    *Mux
+	prefix string
+	decors []Decorating // outermost first
+}
+
+// Group creates a group of routes under the prefix decorated with d.
+// The prefix may be empty to only share the decorators, d may be nil.
+func (m *Mux) Group(prefix string, d Decorating) *Group {
+	g := &Group{mux: m}
+	return g.Group(prefix, d)
+}
+
+// Mount registers all the routes of sub under the prefix.
+// Routes added to sub after the call are not seen by m.
+func (m *Mux) Mount(prefix string, sub *Mux) {
+	m.Group(prefix, nil).Mount("", sub)
+}
+
+// Group creates a sub group under the prefix of g. The handlers of the
+// sub group are decorated with d first and then with the decorators of g.
+func (g *Group) Group(prefix string, d Decorating) *Group {
+	if prefix != "" && prefix[0] != '/' {
+		panic(fmt.Sprintf("ghttp: group prefix %q must begin with '/'", prefix))
+	}
+	sub := &Group{mux: g.mux, prefix: joinPattern(g.prefix, prefix)}
+	sub.decors = append(sub.decors, g.decors...)
+	if d != nil {
+		sub.decors = append(sub.decors, d)
+	}
+	return sub
+}
+
+// Handle registers the decorated Router for the pattern under the prefix
+// of the group and returns the Route. The pattern must begin with '/', or
+// be empty to register the prefix itself.
+func (g *Group) Handle(pattern string, rt Router) *Route {
+	decors := make(map[string][]string, len(rt))
+	for method, h := range rt {
+		decors[method] = decoratorNames(h)
+	}
+	return g.handle(pattern, rt, decors)
+}
+
+func (g *Group) handle(pattern string, rt Router, decors map[string][]string) *Route {
+	if pattern != "" && pattern[0] != '/' {
+		panic(fmt.Sprintf("ghttp: pattern %q must begin with '/'", pattern))
+	}
+	names := g.decoratorNames()
+	drt := make(Router, len(rt))
+	gdecors := make(map[string][]string, len(rt))
+	for method, h := range rt {
+		drt[method] = g.decorate(h)
+		gdecors[method] = append(names[:len(names):len(names)], decors[method]...)
+	}
+	return g.mux.handle(joinPattern(g.prefix, pattern), drt, gdecors)
+}
+
+// HandleMethod registers the decorated Handler for the given method and
+// the pattern under the prefix of the group and returns the Route.
+func (g *Group) HandleMethod(method, pattern string, h Handler) *Route {
+	return g.Handle(pattern, Router{method: h})
+}
+
+// Mount registers all the routes of sub under the prefix of the group,
+// decorated with the decorators of the group.
+// The route names of sub are not carried over, as sub may be mounted
+// more than once. Routes added to sub after the call are not seen by
+// the group.
+func (g *Group) Mount(prefix string, sub *Mux) {
+	mg := g.Group(prefix, nil)
+	for _, rt := range sub.routes {
+		mg.handle(rt.pattern, rt.router, rt.decors)
+	}
+}
+
+func (g *Group) decorate(h Handler) Handler {
+	for i := len(g.decors) - 1; i >= 0; i-- {
+		h = g.decors[i].Decorate(h)
+	}
+	return h
+}
+
+// decoratorNames returns the names of the group decorators, outermost first.
+func (g *Group) decoratorNames() []string {
+	var names []string
+	for _, d := range g.decors {
+		if dn, ok := d.(interface{ Names() []string }); ok {
+			names = append(names, dn.Names()...)
+		} else {
+			names = append(names, fmt.Sprintf("%T", d))
+		}
+	}
+	return names
+}
+
+// joinPattern joins the prefix and the pattern with a single '/'.
+func joinPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+	if pattern == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + pattern
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Simple Decorator
+func tDecorator(tag string) decorator.Decorator {
+	return func(hdl ghttp.Handler) ghttp.Handler {
+		return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+			h.W.Write([]byte("before " + tag + "\n"))
+			c = hdl.ServeHTTPWithCtx(c, h)
+			h.W.Write([]byte("after " + tag + "\n"))
+			return c
+		})
+	}
+}
+
+var groupHandler = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+	h.W.Write([]byte("HandlerFunc " + h.Params.Get("id") + "\n"))
+	return c
+})
+
+//Test cases for nested groups
+func TestGroup(t *testing.T) {
+	mux := ghttp.NewMux()
+	rt := ghttp.Router{"GET": groupHandler}
+
+	mux.Group("", nil).Handle("/health", rt)
+	api := mux.Group("/v1/", decorator.Chain(tDecorator("d2"), tDecorator("d1")))
+	api.Handle("/users/{id}", rt)
+	admin := api.Group("/admin", decorator.Chain(tDecorator("d3")))
+	admin.Handle("/users/{id}", rt)
+	admin.Group("/x", nil).HandleMethod("POST", "/{id}", groupHandler)
+
+	tResultMux(t, mux, "GET", "/health", http.StatusOK, "HandlerFunc \n")
+	tResultMux(t, mux, "GET", "/v1/users/1", http.StatusOK,
+		"before d1\nbefore d2\nHandlerFunc 1\nafter d2\nafter d1\n")
+	tResultMux(t, mux, "GET", "/v1/admin/users/2", http.StatusOK,
+		"before d1\nbefore d2\nbefore d3\nHandlerFunc 2\nafter d3\nafter d2\nafter d1\n")
+	tResultMux(t, mux, "POST", "/v1/admin/x/3", http.StatusOK,
+		"before d1\nbefore d2\nbefore d3\nHandlerFunc 3\nafter d3\nafter d2\nafter d1\n")
+
+	//the Router passed in is not decorated
+	if _, ok := rt["GET"].(ghttp.HandlerFunc); !ok {
+		t.Errorf("Group decorated the Router passed in")
+	}
+}
+
+//Test cases for mounting a Mux
+func TestGroupMount(t *testing.T) {
+	sub := ghttp.NewMux()
+	sub.Handle("/", ghttp.Router{"GET": groupHandler})
+	sub.Handle("/items/{id}", ghttp.Router{"GET": groupHandler})
+
+	mux := ghttp.NewMux()
+	mux.Mount("/shop", sub)
+	mux.Group("/admin", decorator.Chain(tDecorator("d1"))).Mount("/shop", sub)
+
+	tResultMux(t, mux, "GET", "/shop/", http.StatusOK, "HandlerFunc \n")
+	tResultMux(t, mux, "GET", "/shop/items/5", http.StatusOK, "HandlerFunc 5\n")
+	tResultMux(t, mux, "GET", "/admin/shop/items/6", http.StatusOK,
+		"before d1\nHandlerFunc 6\nafter d1\n")
+	tResultMux(t, mux, "GET", "/items/5", http.StatusNotFound, "404 page not found\n")
+}
+
+//Test cases for the patterns of a group without a leading '/'
+func TestGroupInvalidPattern(t *testing.T) {
+	g := ghttp.NewMux().Group("/v1", nil)
+	for _, p := range []string{"users", "{id}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Group Handle %q did not panic", p)
+				}
+			}()
+			g.HandleMethod("GET", p, groupHandler)
+		}()
+	}
+
+	//the empty pattern registers the prefix
+	mux := ghttp.NewMux()
+	mux.Group("/v1", nil).Handle("", ghttp.Router{"GET": groupHandler})
+	tResultMux(t, mux, "GET", "/v1", http.StatusOK, "HandlerFunc \n")
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+// Key is a typed key for the values stored in the Ctx.
+// Keys are compared by identity, so two keys never collide even when they
+// are created with the same name, nor with the keys of other packages.
+// Usage example:
+/*
+	var userKey = ghttp.NewKey[*User]("user")
+
+	//in a decorator
+	c = userKey.With(c, user)
+
+	//in the handler
+	if user, ok := userKey.From(c); ok {
+		...
+	}
+*/
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a new Key. The name is only used for debugging.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// With returns a child Ctx of c that carries the value v.
+func (k *Key[T]) With(c Ctx, v T) Ctx {
+	return ChildCtx(c, k, v)
+}
+
+// From returns the value stored in the Ctx and whether it was found.
+func (k *Key[T]) From(c Ctx) (T, bool) {
+	if c == nil {
+		var zero T
+		return zero, false
+	}
+	v, ok := c.Value(k).(T)
+	return v, ok
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return "ghttp.Key(" + k.name + ")"
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Test cases for the typed keys
+func TestKey(t *testing.T) {
+	strKey := ghttp.NewKey[string]("name")
+	sameName := ghttp.NewKey[string]("name")
+	intKey := ghttp.NewKey[int]("int")
+
+	c := strKey.With(context.Background(), "v1")
+	c = ghttp.ChildCtx(c, 1, "int key")
+	c = intKey.With(c, 7)
+
+	if v, ok := strKey.From(c); !ok || v != "v1" {
+		t.Errorf("Key From failed, got: %s %v, want: v1", v, ok)
+	}
+	if v, ok := sameName.From(c); ok {
+		t.Errorf("Key From collided, got: %s", v)
+	}
+	if v, ok := intKey.From(c); !ok || v != 7 {
+		t.Errorf("Key From failed, got: %d %v, want: 7", v, ok)
+	}
+	if v, ok := intKey.From(nil); ok || v != 0 {
+		t.Errorf("Key From nil Ctx failed, got: %d %v", v, ok)
+	}
+
+	//nil Ctx is replaced by the background context
+	if v, _ := strKey.From(strKey.With(nil, "v2")); v != "v2" {
+		t.Errorf("Key With nil Ctx failed, got: %s, want: v2", v)
+	}
+}
+
+//Test case for concurrent GetNextCtxKey calls
+func TestGetNextCtxKey(t *testing.T) {
+	const n = 100
+	keys := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys <- ghttp.GetNextCtxKey()
+		}()
+	}
+	wg.Wait()
+	close(keys)
+
+	seen := map[int]bool{}
+	for k := range keys {
+		if seen[k] {
+			t.Errorf("GetNextCtxKey returned %d twice", k)
+		}
+		seen[k] = true
+	}
+}
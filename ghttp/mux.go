@@ -0,0 +1,182 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Param is a single path parameter captured by the Mux.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params holds the path parameters captured by the Mux in the order they
+// appear in the pattern.
+type Params []Param
+
+// Get returns the value of the named parameter, or "" if there is none.
+func (ps Params) Get(key string) string {
+	v, _ := ps.Lookup(key)
+	return v
+}
+
+// Lookup returns the value of the named parameter and whether it was found.
+func (ps Params) Lookup(key string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Route is a pattern registered on a Mux.
+type Route struct {
+	mux     *Mux
+	name    string
+	pattern string
+	router  Router
+	decors  map[string][]string // decorator names per method
+}
+
+// Name names the route so that its URL can be built with Mux.URL.
+// Name panics if the name is already used by another route of the Mux.
+func (rt *Route) Name(name string) *Route {
+	if o, ok := rt.mux.names[name]; ok && o != rt {
+		panic(fmt.Sprintf("ghttp: route name %q already used by %s", name, o.pattern))
+	}
+	if rt.name != "" && rt.name != name {
+		delete(rt.mux.names, rt.name)
+	}
+	if rt.mux.names == nil {
+		rt.mux.names = map[string]*Route{}
+	}
+	rt.name = name
+	rt.mux.names[name] = rt
+	return rt
+}
+
+// Pattern returns the pattern of the route.
+func (rt *Route) Pattern() string {
+	return rt.pattern
+}
+
+// Mux dispatches requests on their path to the Router registered for the
+// matching pattern. The Router then dispatches on the request method, so
+// the 405, Allow and OPTIONS handling stays the same for every path.
+//
+// A pattern is made of the following parts:
+//	/users              static text, matched as is
+//	/users/{id}         named param, matches up to the next '/'
+//	/users/{id:[0-9]+}  named param that must match the regexp
+//	/files/*path        catch-all, matches the rest of the path, must be last
+// A param ends at the first occurrence of the static byte that follows it,
+// so /files/{name}.{ext} captures name and ext separately.
+// Static parts are preferred over regexp params, regexp params over plain
+// params and plain params over catch-alls.
+//
+// The captured values are available to the handlers in Http.Params.
+// Usage example:
+/*
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET":getUser, "PUT":putUser})
+	mux.Handle("/users/{id}/orders/{oid:[0-9]+}", ghttp.Router{"GET":getOrder})
+	log.Println(http.ListenAndServe(":8080", mux))
+
+	var getOrder = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		id, oid := h.Params.Get("id"), h.Params.Get("oid")
+		...
+	})
+*/
+type Mux struct {
+	// NotFound handles the requests whose path matches no pattern.
+	// http.NotFound is used when it is nil.
+	NotFound Handler
+
+	tree   node
+	routes []*Route // in registration order
+	names  map[string]*Route
+}
+
+// NewMux returns a new empty Mux.
+func NewMux() *Mux {
+	return &Mux{}
+}
+
+// Handle registers the Router for the given pattern and returns the Route.
+// Registering the same pattern again adds the methods of rt to the existing
+// Router and returns the same Route.
+// Handle panics if the pattern is invalid or a method is already registered
+// for the pattern.
+func (m *Mux) Handle(pattern string, rt Router) *Route {
+	decors := make(map[string][]string, len(rt))
+	for method, h := range rt {
+		decors[method] = decoratorNames(h)
+	}
+	return m.handle(pattern, rt, decors)
+}
+
+func (m *Mux) handle(pattern string, rt Router, decors map[string][]string) *Route {
+	if pattern == "" || pattern[0] != '/' {
+		panic(fmt.Sprintf("ghttp: pattern %q must begin with '/'", pattern))
+	}
+	n, err := m.tree.insert(pattern)
+	if err != nil {
+		panic(err)
+	}
+	if n.route == nil {
+		n.route = &Route{mux: m, pattern: pattern, router: Router{}, decors: map[string][]string{}}
+		m.routes = append(m.routes, n.route)
+	}
+	for method, h := range rt {
+		if _, ok := n.route.router[method]; ok {
+			panic(fmt.Sprintf("ghttp: multiple registrations for %s %s", method, pattern))
+		}
+		n.route.router[method] = h
+		n.route.decors[method] = decors[method]
+	}
+	return n.route
+}
+
+// HandleMethod registers the Handler for the given method and pattern.
+func (m *Mux) HandleMethod(method, pattern string, h Handler) *Route {
+	return m.Handle(pattern, Router{method: h})
+}
+
+// ServeHTTP dispatches the request to the Router of the matching pattern.
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path, raw := r.URL.Path, r.URL.RawPath != ""
+	if raw {
+		path = r.URL.RawPath
+	}
+
+	ps := make(Params, 0, 4)
+	n := m.tree.find(path, &ps)
+	if n == nil {
+		nf := m.NotFound
+		if nf == nil {
+			nf = notFound
+		}
+		nf.ServeHTTPWithCtx(r.Context(), &Http{W: w, R: r, Query: r.URL.Query()})
+		return
+	}
+	if raw {
+		for i := range ps {
+			if v, err := url.PathUnescape(ps[i].Value); err == nil {
+				ps[i].Value = v
+			}
+		}
+	}
+	n.route.router.serve(w, r, n.route.pattern, ps)
+}
+
+var notFound = HandlerFunc(func(c Ctx, h *Http) Ctx {
+	http.NotFound(h.W, h.R)
+	return c
+})
@@ -0,0 +1,189 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Handler that echoes the tag and the captured path params
+func paramHandler(tag string) ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		strs := []string{tag}
+		for _, p := range h.Params {
+			strs = append(strs, p.Key+"="+p.Value)
+		}
+		h.W.Write([]byte(strings.Join(strs, " ")))
+		return c
+	})
+}
+
+//Process mux test results
+func tResultMux(t *testing.T, mux http.Handler, method, target string, wantCode int, want string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, target, nil)
+	mux.ServeHTTP(w, r)
+	if w.Code != wantCode {
+		t.Errorf("%s %s failed, got code: %d, want: %d", method, target, w.Code, wantCode)
+	}
+	if got := w.Body.String(); got != want {
+		t.Errorf("%s %s failed, got: %q, want: %q", method, target, got, want)
+	}
+	return w
+}
+
+func newTestMux() *ghttp.Mux {
+	mux := ghttp.NewMux()
+	mux.Handle("/", ghttp.Router{"GET": paramHandler("root")})
+	mux.Handle("/users", ghttp.Router{"GET": paramHandler("users")})
+	mux.Handle("/users/me", ghttp.Router{"GET": paramHandler("me")})
+	mux.Handle("/users/{id}", ghttp.Router{"GET": paramHandler("user"), "PUT": paramHandler("putuser")})
+	mux.Handle("/users/{id}/orders/{oid:[0-9]+}", ghttp.Router{"GET": paramHandler("order")})
+	mux.Handle("/users/{id}/orders/{name}", ghttp.Router{"GET": paramHandler("ordername")})
+	mux.Handle("/files/{name}.{ext}", ghttp.Router{"GET": paramHandler("file")})
+	mux.Handle("/static/*path", ghttp.Router{"GET": paramHandler("static")})
+	mux.HandleMethod("POST", "/users", paramHandler("postusers"))
+	return mux
+}
+
+//Test cases for the path matching
+func TestMuxMatch(t *testing.T) {
+	mux := newTestMux()
+	tests := []struct {
+		method, target, want string
+	}{
+		{"GET", "/", "root"},
+		{"GET", "/users", "users"},
+		{"POST", "/users", "postusers"},
+		{"GET", "/users/me", "me"},
+		{"GET", "/users/42", "user id=42"},
+		{"PUT", "/users/42", "putuser id=42"},
+		{"GET", "/users/42/orders/7", "order id=42 oid=7"},
+		{"GET", "/users/42/orders/abc", "ordername id=42 name=abc"},
+		{"GET", "/files/report.pdf", "file name=report ext=pdf"},
+		{"GET", "/static/", "static path="},
+		{"GET", "/static/css/site.css", "static path=css/site.css"},
+		{"GET", "/users/a%2Fb", "user id=a/b"},
+	}
+	for _, tt := range tests {
+		tResultMux(t, mux, tt.method, tt.target, http.StatusOK, tt.want)
+	}
+}
+
+//Test cases for the params ending with different terminators, in both
+//registration orders
+func TestMuxParamTails(t *testing.T) {
+	patterns := [][]string{
+		{"/a/{x}.json", "/a/{x}/b"},
+		{"/a/{x}/b", "/a/{x}.json"},
+	}
+	for _, pp := range patterns {
+		mux := ghttp.NewMux()
+		for _, p := range pp {
+			mux.Handle(p, ghttp.Router{"GET": paramHandler(p)})
+		}
+		tResultMux(t, mux, "GET", "/a/foo.json", http.StatusOK, "/a/{x}.json x=foo")
+		tResultMux(t, mux, "GET", "/a/foo/b", http.StatusOK, "/a/{x}/b x=foo")
+	}
+}
+
+//Test cases for 404, 405 and OPTIONS
+func TestMuxNotFoundAndMethods(t *testing.T) {
+	mux := newTestMux()
+
+	tResultMux(t, mux, "GET", "/nothing", http.StatusNotFound, "404 page not found\n")
+	tResultMux(t, mux, "GET", "/users/42/orders", http.StatusNotFound, "404 page not found\n")
+	tResultMux(t, mux, "GET", "/files/report", http.StatusNotFound, "404 page not found\n")
+
+	w := tResultMux(t, mux, "DELETE", "/users/42", http.StatusMethodNotAllowed,
+		http.StatusText(http.StatusMethodNotAllowed)+"\n")
+	if got := w.Header().Get("Allow"); got != "GET, PUT" {
+		t.Errorf("Allow header failed, got: %s, want: %s", got, "GET, PUT")
+	}
+
+	w = tResultMux(t, mux, "OPTIONS", "/users", http.StatusOK, "")
+	if got := w.Header().Get("Allow"); got != "GET, POST" {
+		t.Errorf("Allow header failed, got: %s, want: %s", got, "GET, POST")
+	}
+
+	mux.NotFound = ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		h.W.WriteHeader(http.StatusNotFound)
+		h.W.Write([]byte("custom " + h.R.URL.Path))
+		return c
+	})
+	tResultMux(t, mux, "GET", "/nothing", http.StatusNotFound, "custom /nothing")
+}
+
+//Test case for the Mux served by a real server
+func TestMuxServer(t *testing.T) {
+	ts := httptest.NewServer(newTestMux())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/users/42/orders/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(got) != "order id=42 oid=7" {
+		t.Errorf("Mux server failed, got: %s", got)
+	}
+}
+
+//Test cases for the invalid patterns
+func TestMuxInvalidPattern(t *testing.T) {
+	patterns := []string{
+		"users",
+		"/users/{id",
+		"/users/{}",
+		"/users/{id}{name}",
+		"/users/{id}/{id}",
+		"/files/*path/more",
+		"/users/{id:[0-9}",
+	}
+	for _, p := range patterns {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Handle %q did not panic", p)
+				}
+			}()
+			ghttp.NewMux().Handle(p, ghttp.Router{"GET": paramHandler("x")})
+		}()
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("duplicate registration did not panic")
+			}
+		}()
+		newTestMux().HandleMethod("GET", "/users/{id}", paramHandler("x"))
+	}()
+}
+
+func TestMuxRootCtx(t *testing.T) {
+	type ctxKey struct{}
+	var got interface{}
+	var pattern string
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET": ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		got, pattern = c.Value(ctxKey{}), h.Pattern
+		return c
+	})})
+	r := httptest.NewRequest("GET", "/users/7", nil)
+	r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, "v"))
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "v" || pattern != "/users/{id}" {
+		t.Errorf("Mux root Ctx failed, got: %v %q", got, pattern)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// NamedHandler is implemented by the Handlers created by named decorators,
+// refer to decorator.Named. It lets the Mux list the decorators applied
+// to the registered handlers.
+type NamedHandler interface {
+	Handler
+	DecoratorName() string
+	Unwrap() Handler // the Handler before decoration
+}
+
+// UnnamedDecorator is listed by Routes for the decorator Handlers that
+// can be unwrapped but have no name.
+const UnnamedDecorator = "?"
+
+// decoratorNames returns the names of the decorators h is made of,
+// outermost first. The walk goes through all the Handlers with an Unwrap
+// method, named or not.
+func decoratorNames(h Handler) []string {
+	var names []string
+	for {
+		uh, ok := h.(interface{ Unwrap() Handler })
+		if !ok {
+			return names
+		}
+		name := UnnamedDecorator
+		if nh, ok := h.(NamedHandler); ok && nh.DecoratorName() != "" {
+			name = nh.DecoratorName()
+		}
+		names = append(names, name)
+		h = uh.Unwrap()
+	}
+}
+
+// RouteInfo describes a method registered on a Mux.
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Pattern    string   `json:"pattern"`
+	Name       string   `json:"name,omitempty"`
+	Decorators []string `json:"decorators,omitempty"` // in calling order
+}
+
+// Routes returns all the registered routes, in registration order of the
+// patterns and sorted by method within a pattern.
+// The decorators applied by the groups are listed first, followed by the
+// named decorators applied to the handlers before registration.
+func (m *Mux) Routes() []RouteInfo {
+	var ris []RouteInfo
+	m.Walk(func(ri RouteInfo) error {
+		ris = append(ris, ri)
+		return nil
+	})
+	return ris
+}
+
+// Walk calls fn for each registered route in the order of Routes.
+// The walk stops at the first error returned by fn.
+func (m *Mux) Walk(fn func(RouteInfo) error) error {
+	for _, rt := range m.routes {
+		methods := make([]string, 0, len(rt.router))
+		for method := range rt.router {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			ri := RouteInfo{Method: method, Pattern: rt.pattern, Name: rt.name,
+				Decorators: rt.decors[method]}
+			if err := fn(ri); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteRoutesTable writes the routes as a text table.
+func WriteRoutesTable(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tDECORATORS")
+	for _, ri := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ri.Method, ri.Pattern, ri.Name,
+			strings.Join(ri.Decorators, ", "))
+	}
+	return tw.Flush()
+}
+
+// RoutesHandler creates a debug Handler that lists the routes of the Mux.
+// The routes are written as a text table when the query has format=table
+// or the request accepts text/plain, in the Response envelope as JSON
+// otherwise. Usage example:
+/*
+	mux.Handle("/debug/routes", ghttp.Router{"GET":ghttp.RoutesHandler(mux)})
+*/
+// Refer to cmd/golight for the command that lists the routes of a running
+// service.
+func RoutesHandler(m *Mux) Handler {
+	return HandlerFunc(func(c Ctx, h *Http) Ctx {
+		routes := m.Routes()
+		if h.Query.Get("format") == "table" ||
+			strings.HasPrefix(h.R.Header.Get("Accept"), "text/plain") {
+			h.W.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			WriteRoutesTable(h.W, routes)
+			return c
+		}
+		h.W.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(h.W).Encode(Response{Code: http.StatusOK, Data: routes})
+		return c
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/header"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+)
+
+func newRoutesMux() *ghttp.Mux {
+	mux := ghttp.NewMux()
+	hd := header.CreateDecor(header.HeaderMap{"K": "v"}, true)
+	mux.Handle("/health", ghttp.Router{"GET": groupHandler}).Name("health")
+	api := mux.Group("/v1", decorator.Chain(hd, respond.CreateDecor()))
+	api.Handle("/users/{id}", ghttp.Router{
+		"GET":    groupHandler,
+		"DELETE": decorator.Decorate(groupHandler, decorator.Named("audit", tDecorator("a"))),
+	}).Name("user")
+	mux.Handle("/debug/routes", ghttp.Router{"GET": ghttp.RoutesHandler(mux)})
+	return mux
+}
+
+//Test case for the route listing
+func TestRoutes(t *testing.T) {
+	want := []ghttp.RouteInfo{
+		{Method: "GET", Pattern: "/health", Name: "health"},
+		{Method: "DELETE", Pattern: "/v1/users/{id}", Name: "user", Decorators: []string{"respond", "header", "audit"}},
+		{Method: "GET", Pattern: "/v1/users/{id}", Name: "user", Decorators: []string{"respond", "header"}},
+		{Method: "GET", Pattern: "/debug/routes"},
+	}
+	if got := newRoutesMux().Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes failed, got: %+v, want: %+v", got, want)
+	}
+}
+
+//Handler that wraps another without a decorator name
+type unwrapHandler struct{ ghttp.Handler }
+
+func (uh unwrapHandler) Unwrap() ghttp.Handler { return uh.Handler }
+
+//Test cases for the unnamed decorators, the walk goes past them
+func TestRoutesUnnamed(t *testing.T) {
+	mux := ghttp.NewMux()
+	h := decorator.Decorate(groupHandler, decorator.Named("audit", tDecorator("a")), tDecorator("u"))
+	mux.Handle("/a", ghttp.Router{"GET": decorator.Named("trace", tDecorator("t"))(unwrapHandler{h})})
+
+	want := []string{"trace", ghttp.UnnamedDecorator, "github.com/dlmc/golight/ghttp_test.tDecorator.func1", "audit"}
+	if got := mux.Routes()[0].Decorators; !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes unnamed failed, got: %v, want: %v", got, want)
+	}
+}
+
+//Test cases for the debug handler
+func TestRoutesHandler(t *testing.T) {
+	mux := newRoutesMux()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/debug/routes", nil))
+	var routes []ghttp.RouteInfo
+	if err := json.NewDecoder(w.Body).Decode(&ghttp.Response{Data: &routes}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(routes, mux.Routes()) {
+		t.Errorf("RoutesHandler json failed, got: %+v", routes)
+	}
+
+	table := "METHOD  PATTERN         NAME    DECORATORS\n" +
+		"GET     /health         health  \n" +
+		"DELETE  /v1/users/{id}  user    respond, header, audit\n" +
+		"GET     /v1/users/{id}  user    respond, header\n" +
+		"GET     /debug/routes           \n"
+	tResultMux(t, mux, "GET", "/debug/routes?format=table", http.StatusOK, table)
+}
@@ -0,0 +1,255 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The path patterns are stored in a radix tree. Each node holds either a
+// static prefix or a single parameter token. Static edges are compressed
+// and split on demand; parameter edges hang off the node that precedes
+// them. Lookups try the children in the order static, regexp, param and
+// catch-all, backtracking when a branch does not lead to a route.
+
+type nodeTyp uint8
+
+const (
+	ntStatic   nodeTyp = iota // /users
+	ntRegexp                  // /{id:[0-9]+}
+	ntParam                   // /{id}
+	ntCatchAll                // /*path
+)
+
+type node struct {
+	typ      nodeTyp
+	label    byte   // first byte of the prefix
+	tail     byte   // byte that terminates a param value, '/' by default
+	prefix   string // static prefix or the raw param token
+	key      string // param name
+	rex      *regexp.Regexp
+	children [ntCatchAll + 1]nodes
+	route    *Route // non-nil on nodes that terminate a registered pattern
+}
+
+type nodes []*node
+
+// findEdge returns the static child with the given label.
+func (ns nodes) findEdge(label byte) *node {
+	i := sort.Search(len(ns), func(i int) bool { return ns[i].label >= label })
+	if i < len(ns) && ns[i].label == label {
+		return ns[i]
+	}
+	return nil
+}
+
+func (n *node) addChild(child *node) {
+	ns := append(n.children[child.typ], child)
+	if child.typ == ntStatic {
+		sort.Slice(ns, func(i, j int) bool { return ns[i].label < ns[j].label })
+	}
+	n.children[child.typ] = ns
+}
+
+func (n *node) replaceChild(label byte, child *node) {
+	ns := n.children[ntStatic]
+	for i := range ns {
+		if ns[i].label == label {
+			ns[i] = child
+			return
+		}
+	}
+}
+
+// insert adds the pattern into the tree and returns the node it ends on.
+func (n *node) insert(pattern string) (*node, error) {
+	search := pattern
+	keys := map[string]bool{}
+	for len(search) > 0 {
+		if search[0] == '{' || search[0] == '*' {
+			tk, err := parseToken(search)
+			if err != nil {
+				return nil, fmt.Errorf("ghttp: pattern %q: %v", pattern, err)
+			}
+			if keys[tk.key] {
+				return nil, fmt.Errorf("ghttp: pattern %q: duplicate param %q", pattern, tk.key)
+			}
+			keys[tk.key] = true
+			var child *node
+			for _, c := range n.children[tk.typ] {
+				//{x}.json and {x}/b share the raw token, not the terminator
+				if c.prefix == tk.raw && c.tail == tk.tail {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				if tk.typ == ntCatchAll && len(n.children[ntCatchAll]) > 0 {
+					return nil, fmt.Errorf("ghttp: pattern %q: conflicting catch-all %q",
+						pattern, n.children[ntCatchAll][0].prefix)
+				}
+				child = &node{typ: tk.typ, label: search[0], tail: tk.tail,
+					prefix: tk.raw, key: tk.key, rex: tk.rex}
+				n.addChild(child)
+			}
+			n = child
+			search = search[len(tk.raw):]
+			continue
+		}
+
+		seg := search
+		if i := strings.IndexAny(search, "{*"); i >= 0 {
+			seg = search[:i]
+		}
+		child := n.children[ntStatic].findEdge(seg[0])
+		if child == nil {
+			child = &node{typ: ntStatic, label: seg[0], prefix: seg}
+			n.addChild(child)
+			n = child
+			search = search[len(seg):]
+			continue
+		}
+		l := longestPrefix(seg, child.prefix)
+		if l < len(child.prefix) {
+			// Split the edge at the common prefix
+			split := &node{typ: ntStatic, label: seg[0], prefix: seg[:l]}
+			n.replaceChild(child.label, split)
+			child.prefix = child.prefix[l:]
+			child.label = child.prefix[0]
+			split.addChild(child)
+			child = split
+		}
+		n = child
+		search = search[l:]
+	}
+	return n, nil
+}
+
+// find looks up the path and appends the captured params to ps.
+// It returns the node of the matched route or nil.
+func (n *node) find(path string, ps *Params) *node {
+	if path == "" {
+		if n.route != nil {
+			return n
+		}
+		// Only a catch-all may match an empty remainder
+		if ns := n.children[ntCatchAll]; len(ns) > 0 && ns[0].route != nil {
+			*ps = append(*ps, Param{Key: ns[0].key})
+			return ns[0]
+		}
+		return nil
+	}
+
+	if xn := n.children[ntStatic].findEdge(path[0]); xn != nil && strings.HasPrefix(path, xn.prefix) {
+		if fn := xn.find(path[len(xn.prefix):], ps); fn != nil {
+			return fn
+		}
+	}
+
+	for _, typ := range [...]nodeTyp{ntRegexp, ntParam} {
+		for _, xn := range n.children[typ] {
+			end := strings.IndexByte(path, xn.tail)
+			if slash := strings.IndexByte(path, '/'); xn.tail != '/' && slash >= 0 && (end < 0 || slash < end) {
+				// The tail must be found within the current segment
+				continue
+			}
+			if end < 0 {
+				if xn.tail != '/' {
+					continue
+				}
+				end = len(path)
+			}
+			if end == 0 {
+				continue
+			}
+			val := path[:end]
+			if typ == ntRegexp && !xn.rex.MatchString(val) {
+				continue
+			}
+			mark := len(*ps)
+			*ps = append(*ps, Param{Key: xn.key, Value: val})
+			if fn := xn.find(path[end:], ps); fn != nil {
+				return fn
+			}
+			*ps = (*ps)[:mark]
+		}
+	}
+
+	if ns := n.children[ntCatchAll]; len(ns) > 0 && ns[0].route != nil {
+		*ps = append(*ps, Param{Key: ns[0].key, Value: path})
+		return ns[0]
+	}
+	return nil
+}
+
+type token struct {
+	typ  nodeTyp
+	raw  string
+	key  string
+	rex  *regexp.Regexp
+	tail byte
+}
+
+// parseToken parses the {param}, {param:regexp} or *wildcard token at the
+// start of s.
+func parseToken(s string) (token, error) {
+	if s[0] == '*' {
+		if strings.ContainsAny(s[1:], "/{}*") {
+			return token{}, fmt.Errorf("catch-all %q must be the last part of the pattern", s)
+		}
+		key := s[1:]
+		if key == "" {
+			key = "*"
+		}
+		return token{typ: ntCatchAll, raw: s, key: key}, nil
+	}
+
+	// Find the matching closing brace, regexps may contain braces
+	depth, end := 0, -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				end = i + 1
+			}
+		}
+	}
+	if end < 0 {
+		return token{}, fmt.Errorf("missing closing '}' in %q", s)
+	}
+
+	tk := token{typ: ntParam, raw: s[:end], key: s[1 : end-1], tail: '/'}
+	if i := strings.IndexByte(tk.key, ':'); i >= 0 {
+		rex, err := regexp.Compile("^(?:" + tk.key[i+1:] + ")$")
+		if err != nil {
+			return token{}, err
+		}
+		tk.typ, tk.key, tk.rex = ntRegexp, tk.key[:i], rex
+	}
+	if tk.key == "" || strings.ContainsAny(tk.key, "/{}*") {
+		return token{}, fmt.Errorf("invalid param name in %q", tk.raw)
+	}
+	if end < len(s) {
+		switch s[end] {
+		case '{', '*':
+			return token{}, fmt.Errorf("param %q must be followed by a static part", tk.raw)
+		}
+		tk.tail = s[end]
+	}
+	return tk, nil
+}
+
+func longestPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
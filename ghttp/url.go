@@ -0,0 +1,93 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL builds the URL path of the route named name. The params are the
+// key / value pairs substituted into the pattern, the values are path
+// escaped. The query values q, if any, are appended to the path.
+// Usage example:
+/*
+	mux.Handle("/users/{id}/orders/{oid:[0-9]+}", ghttp.Router{"GET":getOrder}).Name("order")
+
+	u, err := mux.URL("order", url.Values{"expand":{"items"}}, "id", "a b", "oid", "7")
+	// u == "/users/a%20b/orders/7?expand=items"
+*/
+// An error is returned when the route is not found, a param is missing,
+// unknown or does not match the pattern.
+func (m *Mux) URL(name string, q url.Values, params ...string) (string, error) {
+	rt, ok := m.names[name]
+	if !ok {
+		return "", fmt.Errorf("ghttp: route %q not found", name)
+	}
+	return rt.URL(q, params...)
+}
+
+// URL builds the URL path of the route, refer to Mux.URL for details.
+func (rt *Route) URL(q url.Values, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("ghttp: route %s: odd number of params", rt.pattern)
+	}
+	vals := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		vals[params[i]] = params[i+1]
+	}
+
+	var b strings.Builder
+	search := rt.pattern
+	for len(search) > 0 {
+		i := strings.IndexAny(search, "{*")
+		if i < 0 {
+			b.WriteString(search)
+			break
+		}
+		b.WriteString(search[:i])
+		search = search[i:]
+
+		tk, err := parseToken(search)
+		if err != nil {
+			return "", err
+		}
+		search = search[len(tk.raw):]
+		v, ok := vals[tk.key]
+		if !ok && tk.typ != ntCatchAll {
+			return "", fmt.Errorf("ghttp: route %s: missing param %q", rt.pattern, tk.key)
+		}
+		delete(vals, tk.key)
+
+		switch tk.typ {
+		case ntCatchAll:
+			segs := strings.Split(v, "/")
+			for i := range segs {
+				segs[i] = url.PathEscape(segs[i])
+			}
+			b.WriteString(strings.Join(segs, "/"))
+			continue
+		case ntRegexp:
+			if !tk.rex.MatchString(v) {
+				return "", fmt.Errorf("ghttp: route %s: param %q value %q does not match %s",
+					rt.pattern, tk.key, v, tk.rex)
+			}
+		}
+		if v == "" || (tk.tail != '/' && strings.IndexByte(v, tk.tail) >= 0) {
+			return "", fmt.Errorf("ghttp: route %s: invalid param %q value %q", rt.pattern, tk.key, v)
+		}
+		b.WriteString(url.PathEscape(v))
+	}
+
+	for k := range vals {
+		return "", fmt.Errorf("ghttp: route %s: unknown param %q", rt.pattern, k)
+	}
+	if len(q) > 0 {
+		b.WriteByte('?')
+		b.WriteString(q.Encode())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Test cases for building URLs from named routes
+func TestURL(t *testing.T) {
+	mux := ghttp.NewMux()
+	mux.Handle("/users/{id}", ghttp.Router{"GET": paramHandler("user")}).Name("user")
+	mux.Handle("/users/{id}/orders/{oid:[0-9]+}", ghttp.Router{"GET": paramHandler("order")}).Name("order")
+	mux.Handle("/files/{name}.{ext}", ghttp.Router{"GET": paramHandler("file")}).Name("file")
+	mux.Group("/v1", nil).Handle("/static/*path", ghttp.Router{"GET": paramHandler("static")}).Name("static")
+
+	tests := []struct {
+		name   string
+		q      url.Values
+		params []string
+		want   string
+	}{
+		{"user", nil, []string{"id", "42"}, "/users/42"},
+		{"user", nil, []string{"id", "a b/c"}, "/users/a%20b%2Fc"},
+		{"order", url.Values{"expand": {"items"}, "a": {"1&2"}}, []string{"id", "42", "oid", "7"},
+			"/users/42/orders/7?a=1%262&expand=items"},
+		{"file", nil, []string{"name", "report", "ext", "pdf"}, "/files/report.pdf"},
+		{"static", nil, []string{"path", "css/a b.css"}, "/v1/static/css/a%20b.css"},
+		{"static", nil, nil, "/v1/static/"},
+	}
+	for _, tt := range tests {
+		got, err := mux.URL(tt.name, tt.q, tt.params...)
+		if err != nil || got != tt.want {
+			t.Errorf("URL %s failed, got: %s %v, want: %s", tt.name, got, err, tt.want)
+		}
+	}
+
+	//The built URLs route back to the same params
+	u, _ := mux.URL("user", nil, "id", "a b/c")
+	tResultMux(t, mux, "GET", u, http.StatusOK, "user id=a b/c")
+
+	errs := []struct {
+		name   string
+		params []string
+	}{
+		{"nothing", nil},
+		{"user", nil},
+		{"user", []string{"id"}},
+		{"user", []string{"id", ""}},
+		{"user", []string{"id", "1", "x", "2"}},
+		{"order", []string{"id", "42", "oid", "x7"}},
+		{"file", []string{"name", "re.port", "ext", "pdf"}},
+	}
+	for _, tt := range errs {
+		if got, err := mux.URL(tt.name, nil, tt.params...); err == nil {
+			t.Errorf("URL %s %v did not fail, got: %s", tt.name, tt.params, got)
+		}
+	}
+}
+
+//Test case for duplicate route names
+func TestURLDuplicateName(t *testing.T) {
+	mux := ghttp.NewMux()
+	mux.Handle("/a", ghttp.Router{"GET": paramHandler("a")}).Name("a")
+	mux.Handle("/a", ghttp.Router{"POST": paramHandler("a")}).Name("a")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate name did not panic")
+		}
+	}()
+	mux.Handle("/b", ghttp.Router{"GET": paramHandler("b")}).Name("a")
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is an http.ResponseWriter that records the status code
+// and the number of bytes written, for the decorators that need to know
+// what happened to the response.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	Status() int   // the status code written, 0 if none yet
+	Size() int64   // the number of body bytes written
+	Written() bool // whether the header has been written
+	Unwrap() http.ResponseWriter
+}
+
+// WrapWriter wraps w into a ResponseWriter. w is returned as is when it
+// already is a ResponseWriter.
+// Usage example:
+/*
+	w := ghttp.WrapWriter(h.W)
+	orig := h.W
+	h.W = w
+	c = next.ServeHTTPWithCtx(c, h)
+	h.W = orig
+	status, size := w.Status(), w.Size()
+*/
+func WrapWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.status == 0 || code < 200 {
+		// Informational headers may precede the final header
+		if code >= 200 {
+			rw.status = code
+		}
+		rw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Status() int                 { return rw.status }
+func (rw *responseWriter) Size() int64                 { return rw.size }
+func (rw *responseWriter) Written() bool               { return rw.status != 0 }
+func (rw *responseWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
+
+func (rw *responseWriter) Flush() {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the websocket like handlers take over the connection.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("ghttp: the ResponseWriter does not support Hijack")
+}
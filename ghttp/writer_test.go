@@ -0,0 +1,42 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ghttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+//Test cases for the ResponseWriter
+func TestWrapWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := ghttp.WrapWriter(rec)
+	if w.Written() || w.Status() != 0 {
+		t.Errorf("WrapWriter failed, got: %v %d", w.Written(), w.Status())
+	}
+	if ghttp.WrapWriter(w) != w {
+		t.Errorf("WrapWriter wrapped a ResponseWriter twice")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("hello"))
+	w.Write([]byte(" world"))
+	if w.Status() != http.StatusCreated || w.Size() != 11 || !w.Written() || rec.Code != http.StatusCreated {
+		t.Errorf("WrapWriter failed, got: %d %d %v", w.Status(), w.Size(), w.Written())
+	}
+	if w.Unwrap() != rec {
+		t.Errorf("WrapWriter Unwrap failed")
+	}
+
+	w = ghttp.WrapWriter(httptest.NewRecorder())
+	w.Write([]byte("x"))
+	if w.Status() != http.StatusOK {
+		t.Errorf("WrapWriter implicit status failed, got: %d", w.Status())
+	}
+}
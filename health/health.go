@@ -0,0 +1,271 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package health aggregates the named checks of the components into the
+// liveness and readiness handlers of the orchestration, e.g. /healthz and
+// /readyz. The readiness fails while the server drains its requests.
+// Usage example:
+/*
+	reg := health.New()
+	reg.Register(health.Check{Name: "db", Critical: true, Timeout: time.Second,
+		Interval: 5 * time.Second, Check: func(c ghttp.Ctx) error { return db.PingContext(c) }})
+	reg.Register(health.Check{Name: "cache", Check: pingCache})
+
+	mux := ghttp.NewMux()
+	mux.Handle("/healthz", ghttp.Router{"GET": decorator.Decorate(reg.Liveness(), respond.CreateDecor())})
+	mux.Handle("/readyz", ghttp.Router{"GET": decorator.Decorate(reg.Readiness(), respond.CreateDecor())})
+
+	srv := server.New(server.Config{Addr: ":8080", Handler: mux, ShutdownDelay: 5 * time.Second})
+	reg.DrainWith(srv)
+	srv.ListenAndServe()
+*/
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dlmc/golight/ghttp"
+)
+
+// The statuses of the checks and of the reports.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded" // only non-critical checks are down
+	StatusDraining = "draining" // the server is shutting down
+)
+
+// DefaultTimeout limits the checks without a Timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Check is a named check of a component.
+type Check struct {
+	Name  string
+	Check func(c ghttp.Ctx) error // nil error when the component is healthy
+
+	// Timeout limits the check, DefaultTimeout if 0. The Ctx of the check
+	// is canceled on timeout.
+	Timeout time.Duration
+	// Critical checks fail the report when they are down, the others only
+	// degrade it.
+	Critical bool
+	// Interval caches the result of the check, so that the frequent probes
+	// do not load the component. The check runs on every probe if 0.
+	// The cached checks are not canceled with the probe, only by Timeout.
+	Interval time.Duration
+	// Liveness adds the check to the liveness as well, e.g. a deadlock
+	// detector. The failed liveness gets the process restarted, so most
+	// checks belong to the readiness only.
+	Liveness bool
+}
+
+// Result is the result of a check in the report.
+type Result struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Critical bool    `json:"critical,omitempty"`
+	Latency  float64 `json:"latency_ms"`
+	Error    string  `json:"error,omitempty"`
+	Cached   bool    `json:"cached,omitempty"`
+}
+
+// Report is the aggregated result of the checks, sent in the Data of the
+// ghttp.Response.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+// Drainer reports whether the server is shutting down, e.g. server.Server.
+type Drainer interface {
+	Draining() bool
+}
+
+// Registry holds the registered checks. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*entry
+	drainers []Drainer
+}
+
+type entry struct {
+	Check
+
+	mu     sync.Mutex
+	last   Result
+	expiry time.Time
+}
+
+// New creates an empty Registry.
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register adds the check. It panics on a check without a name or a func,
+// or with the name of a registered check.
+func (reg *Registry) Register(chk Check) {
+	if chk.Name == "" || chk.Check == nil {
+		panic("health: the check needs a name and a func")
+	}
+	if chk.Timeout <= 0 {
+		chk.Timeout = DefaultTimeout
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, e := range reg.checks {
+		if e.Name == chk.Name {
+			panic("health: duplicate check " + chk.Name)
+		}
+	}
+	reg.checks = append(reg.checks, &entry{Check: chk})
+}
+
+// DrainWith fails the readiness while d is draining.
+func (reg *Registry) DrainWith(d Drainer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.drainers = append(reg.drainers, d)
+}
+
+// Draining reports whether one of the Drainers is draining.
+func (reg *Registry) Draining() bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, d := range reg.drainers {
+		if d.Draining() {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the checks concurrently, the liveness checks only if liveness,
+// and aggregates their results in the order of their registration.
+func (reg *Registry) Run(c ghttp.Ctx, liveness bool) Report {
+	if c == nil {
+		c = context.Background()
+	}
+	reg.mu.RLock()
+	var entries []*entry
+	for _, e := range reg.checks {
+		if !liveness || e.Liveness {
+			entries = append(entries, e)
+		}
+	}
+	reg.mu.RUnlock()
+
+	rep := Report{Status: StatusUp, Checks: make([]Result, len(entries))}
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			rep.Checks[i] = e.result(c)
+		}(i, e)
+	}
+	wg.Wait()
+	for _, r := range rep.Checks {
+		if r.Status == StatusUp {
+			continue
+		}
+		if r.Critical {
+			rep.Status = StatusDown
+			break
+		}
+		rep.Status = StatusDegraded
+	}
+	return rep
+}
+
+// Liveness returns the handler of the liveness, e.g. /healthz. It sets
+// h.Resp to 200 with the Report, or to 503 when a critical liveness check
+// is down. Decorate it with respond.CreateDecor to send h.Resp.
+func (reg *Registry) Liveness() ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		setResp(h, reg.Run(c, true))
+		return c
+	})
+}
+
+// Readiness returns the handler of the readiness, e.g. /readyz. It sets
+// h.Resp to 200 with the Report, or to 503 when a critical check is down
+// or the server is draining. The checks are not run while draining.
+// Decorate it with respond.CreateDecor to send h.Resp.
+func (reg *Registry) Readiness() ghttp.Handler {
+	return ghttp.HandlerFunc(func(c ghttp.Ctx, h *ghttp.Http) ghttp.Ctx {
+		if reg.Draining() {
+			setResp(h, Report{Status: StatusDraining})
+			return c
+		}
+		setResp(h, reg.Run(c, false))
+		return c
+	})
+}
+
+func setResp(h *ghttp.Http, rep Report) {
+	code := http.StatusOK
+	if rep.Status == StatusDown || rep.Status == StatusDraining {
+		code = http.StatusServiceUnavailable
+	}
+	h.Resp = ghttp.Response{Code: code, Message: rep.Status, Data: rep}
+}
+
+// result returns the cached result of the check, or runs it.
+func (e *entry) result(c ghttp.Ctx) Result {
+	if e.Interval > 0 {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if time.Now().Before(e.expiry) {
+			r := e.last
+			r.Cached = true
+			return r
+		}
+	}
+	if e.Interval > 0 {
+		//the result is shared by the probes, so it must not depend on the
+		//cancellation of the probe that runs the check
+		c = context.WithoutCancel(c)
+	}
+	start := time.Now()
+	err := e.run(c)
+	r := Result{Name: e.Name, Status: StatusUp, Critical: e.Critical,
+		Latency: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		r.Status, r.Error = StatusDown, err.Error()
+	}
+	if e.Interval > 0 {
+		e.last, e.expiry = r, time.Now().Add(e.Interval)
+	}
+	return r
+}
+
+// run runs the check within its timeout. A check ignoring the canceled
+// Ctx is abandoned, and a panic of the check is returned as its error.
+func (e *entry) run(c ghttp.Ctx) error {
+	ctx, cancel := context.WithTimeout(c, e.Timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("health: check panicked: %v", v)
+			}
+		}()
+		done <- e.Check.Check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("health: check timed out after %v", e.Timeout)
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator"
+	"github.com/dlmc/golight/decorator/respond"
+	"github.com/dlmc/golight/ghttp"
+	"github.com/dlmc/golight/health"
+)
+
+type drainer struct{ draining atomic.Bool }
+
+func (d *drainer) Draining() bool { return d.draining.Load() }
+
+type response struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    health.Report `json:"data"`
+}
+
+func serve(t *testing.T, hdl ghttp.Handler) (int, response) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ghttp.Router{"GET": decorator.Decorate(hdl, respond.CreateDecor())}.ServeHTTP(w,
+		httptest.NewRequest("GET", "/readyz", nil))
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid response %s: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+//Test cases for the readiness and the liveness
+func TestHealth(t *testing.T) {
+	var dbErr, cacheErr atomic.Value
+	check := func(v *atomic.Value) func(ghttp.Ctx) error {
+		return func(ghttp.Ctx) error {
+			if msg := v.Load().(string); msg != "" {
+				return errors.New(msg)
+			}
+			return nil
+		}
+	}
+	reg := health.New()
+	reg.Register(health.Check{Name: "db", Critical: true, Check: check(&dbErr)})
+	reg.Register(health.Check{Name: "cache", Check: check(&cacheErr)})
+	reg.Register(health.Check{Name: "loop", Critical: true, Liveness: true,
+		Check: func(ghttp.Ctx) error { return nil }})
+	d := &drainer{}
+	reg.DrainWith(d)
+
+	testCases := []struct {
+		dbErr, cacheErr string
+		draining        bool
+		code            int
+		status          string
+		checks          int
+	}{
+		{"", "", false, 200, health.StatusUp, 3},
+		{"", "timeout", false, 200, health.StatusDegraded, 3},
+		{"refused", "", false, 503, health.StatusDown, 3},
+		{"", "", true, 503, health.StatusDraining, 0},
+	}
+	for i, tc := range testCases {
+		dbErr.Store(tc.dbErr)
+		cacheErr.Store(tc.cacheErr)
+		d.draining.Store(tc.draining)
+		code, resp := serve(t, reg.Readiness())
+		if code != tc.code || resp.Message != tc.status || resp.Data.Status != tc.status ||
+			len(resp.Data.Checks) != tc.checks {
+			t.Errorf("Test case %d failed, got: %d %+v", i, code, resp)
+		}
+		if tc.dbErr != "" && (resp.Data.Checks[0].Error != tc.dbErr || !resp.Data.Checks[0].Critical) {
+			t.Errorf("Test case %d failed, got: %+v", i, resp.Data.Checks[0])
+		}
+
+		code, resp = serve(t, reg.Liveness())
+		if code != 200 || resp.Data.Status != health.StatusUp || len(resp.Data.Checks) != 1 ||
+			resp.Data.Checks[0].Name != "loop" {
+			t.Errorf("Test case %d liveness failed, got: %d %+v", i, code, resp)
+		}
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	reg := health.New()
+	reg.Register(health.Check{Name: "slow", Critical: true, Timeout: 10 * time.Millisecond,
+		Check: func(c ghttp.Ctx) error { <-c.Done(); return nil }})
+	reg.Register(health.Check{Name: "stuck", Timeout: 10 * time.Millisecond,
+		Check: func(c ghttp.Ctx) error { time.Sleep(time.Second); return nil }})
+	reg.Register(health.Check{Name: "panic", Check: func(c ghttp.Ctx) error { panic("boom") }})
+
+	start := time.Now()
+	rep := reg.Run(nil, false)
+	if time.Since(start) > 500*time.Millisecond || rep.Status != health.StatusDown {
+		t.Errorf("Check timeout failed, got: %v %+v", time.Since(start), rep)
+	}
+	for i, want := range []string{"health: check timed out after 10ms", "health: check timed out after 10ms",
+		"health: check panicked: boom"} {
+		if r := rep.Checks[i]; r.Status != health.StatusDown || r.Error != want {
+			t.Errorf("Check %d failed, got: %+v", i, r)
+		}
+	}
+}
+
+func TestCheckCache(t *testing.T) {
+	var calls int32
+	reg := health.New()
+	reg.Register(health.Check{Name: "cached", Interval: 50 * time.Millisecond,
+		Check: func(ghttp.Ctx) error { atomic.AddInt32(&calls, 1); return nil }})
+
+	if r := reg.Run(nil, false).Checks[0]; r.Cached {
+		t.Errorf("Check cache failed, got: %+v", r)
+	}
+	if r := reg.Run(nil, false).Checks[0]; !r.Cached || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Check cache failed, got: %+v %d", r, calls)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if r := reg.Run(nil, false).Checks[0]; r.Cached || atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("Check cache expiry failed, got: %+v %d", r, calls)
+	}
+}
+
+//Test cases for a cached check whose probe is canceled
+func TestCheckCacheCanceled(t *testing.T) {
+	reg := health.New()
+	reg.Register(health.Check{Name: "cached", Interval: time.Minute,
+		Check: func(c ghttp.Ctx) error {
+			select {
+			case <-c.Done():
+				return c.Err()
+			case <-time.After(20 * time.Millisecond):
+				return nil
+			}
+		}})
+
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r := reg.Run(c, false).Checks[0]; r.Status != health.StatusUp {
+		t.Errorf("Check cache canceled failed, got: %+v", r)
+	}
+	if r := reg.Run(nil, false).Checks[0]; !r.Cached || r.Status != health.StatusUp {
+		t.Errorf("Check cache canceled failed, got: %+v", r)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	reg := health.New()
+	reg.Register(health.Check{Name: "db", Check: func(ghttp.Ctx) error { return nil }})
+	for i, chk := range []health.Check{{Name: "db", Check: func(ghttp.Ctx) error { return nil }},
+		{Name: "nil"}, {Check: func(ghttp.Ctx) error { return nil }}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Test case %d did not panic", i)
+				}
+			}()
+			reg.Register(chk)
+		}()
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"time"
+)
+
+// Context adds the fields of a sub-logger, created by Logger.With:
+//	sub := log.With().Str("component", "db").Logger()
+// A Context never shares its fields with another one, so the contexts and
+// the sub-loggers derived from the same parent do not interfere.
+type Context struct {
+	l Logger
+}
+
+// Logger returns the sub-logger with the fields of the context.
+func (c Context) Logger() Logger {
+	return c.l
+}
+
+// Timestamp adds the timestamp to the events of the sub-logger.
+func (c Context) Timestamp() Context {
+	c.l.timestamp = true
+	return c
+}
+
+// Err adds the error field with the message of err, if not nil.
+func (c Context) Err(err error) Context {
+	if err != nil {
+		c.l.context = appendString(appendKey(c.fields(), ErrorFieldName), err.Error())
+	}
+	return c
+}
+
+// fields returns the fields of the context with no spare capacity, so that
+// the appends copy them instead of writing into the shared array.
+func (c Context) fields() []byte {
+	return c.l.context[:len(c.l.context):len(c.l.context)]
+}
+
+// Str adds the field key with the string.
+func (c Context) Str(key string, val string) Context {
+	c.l.context = appendString(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Strs adds the field key with the strings.
+func (c Context) Strs(key string, val []string) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendString)
+	return c
+}
+
+// Bool adds the field key with the bool.
+func (c Context) Bool(key string, val bool) Context {
+	c.l.context = appendBool(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Bools adds the field key with the bools.
+func (c Context) Bools(key string, val []bool) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendBool)
+	return c
+}
+
+// Int adds the field key with the int.
+func (c Context) Int(key string, val int) Context {
+	c.l.context = appendInt(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Ints adds the field key with the ints.
+func (c Context) Ints(key string, val []int) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendInt[int])
+	return c
+}
+
+// Int8 adds the field key with the int8.
+func (c Context) Int8(key string, val int8) Context {
+	c.l.context = appendInt(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Ints8 adds the field key with the int8s.
+func (c Context) Ints8(key string, val []int8) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendInt[int8])
+	return c
+}
+
+// Int16 adds the field key with the int16.
+func (c Context) Int16(key string, val int16) Context {
+	c.l.context = appendInt(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Ints16 adds the field key with the int16s.
+func (c Context) Ints16(key string, val []int16) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendInt[int16])
+	return c
+}
+
+// Int32 adds the field key with the int32.
+func (c Context) Int32(key string, val int32) Context {
+	c.l.context = appendInt(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Ints32 adds the field key with the int32s.
+func (c Context) Ints32(key string, val []int32) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendInt[int32])
+	return c
+}
+
+// Int64 adds the field key with the int64.
+func (c Context) Int64(key string, val int64) Context {
+	c.l.context = appendInt(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Ints64 adds the field key with the int64s.
+func (c Context) Ints64(key string, val []int64) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendInt[int64])
+	return c
+}
+
+// Uint adds the field key with the uint.
+func (c Context) Uint(key string, val uint) Context {
+	c.l.context = appendUint(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Uints adds the field key with the uints.
+func (c Context) Uints(key string, val []uint) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendUint[uint])
+	return c
+}
+
+// Uint8 adds the field key with the uint8.
+func (c Context) Uint8(key string, val uint8) Context {
+	c.l.context = appendUint(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Uints8 adds the field key with the uint8s, as numbers.
+func (c Context) Uints8(key string, val []uint8) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendUint[uint8])
+	return c
+}
+
+// Uint16 adds the field key with the uint16.
+func (c Context) Uint16(key string, val uint16) Context {
+	c.l.context = appendUint(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Uints16 adds the field key with the uint16s.
+func (c Context) Uints16(key string, val []uint16) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendUint[uint16])
+	return c
+}
+
+// Uint32 adds the field key with the uint32.
+func (c Context) Uint32(key string, val uint32) Context {
+	c.l.context = appendUint(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Uints32 adds the field key with the uint32s.
+func (c Context) Uints32(key string, val []uint32) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendUint[uint32])
+	return c
+}
+
+// Uint64 adds the field key with the uint64.
+func (c Context) Uint64(key string, val uint64) Context {
+	c.l.context = appendUint(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Uints64 adds the field key with the uint64s.
+func (c Context) Uints64(key string, val []uint64) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendUint[uint64])
+	return c
+}
+
+// Float32 adds the field key with the float32.
+func (c Context) Float32(key string, val float32) Context {
+	c.l.context = appendFloat32(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Floats32 adds the field key with the float32s.
+func (c Context) Floats32(key string, val []float32) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendFloat32)
+	return c
+}
+
+// Float64 adds the field key with the float64.
+func (c Context) Float64(key string, val float64) Context {
+	c.l.context = appendFloat64(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Floats64 adds the field key with the float64s.
+func (c Context) Floats64(key string, val []float64) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendFloat64)
+	return c
+}
+
+// Time adds the field key with the time in TimeFormat.
+func (c Context) Time(key string, val time.Time) Context {
+	c.l.context = appendTime(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Times adds the field key with the times in TimeFormat.
+func (c Context) Times(key string, val []time.Time) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendTime)
+	return c
+}
+
+// Dur adds the field key with the duration as an integer of DurationUnit.
+func (c Context) Dur(key string, val time.Duration) Context {
+	c.l.context = appendDuration(appendKey(c.fields(), key), val)
+	return c
+}
+
+// Durs adds the field key with the durations as integers of DurationUnit.
+func (c Context) Durs(key string, val []time.Duration) Context {
+	c.l.context = appendArray(appendKey(c.fields(), key), val, appendDuration)
+	return c
+}
+
+// Interface adds the field key with the JSON of val, e.g. a LogObj.
+func (c Context) Interface(key string, val interface{}) Context {
+	c.l.context = appendInterface(appendKey(c.fields(), key), val)
+	return c
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// The JSON encoders below append to the buffer of the event or of the
+// context without allocating.
+
+const hexDigits = "0123456789abcdef"
+
+// appendKey appends the comma separator if needed and the key.
+func appendKey(b []byte, key string) []byte {
+	if len(b) > 0 && b[len(b)-1] != '{' {
+		b = append(b, ',')
+	}
+	return append(appendString(b, key), ':')
+}
+
+// appendString appends s as a JSON string. The invalid UTF-8 bytes are
+// replaced by U+FFFD.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c > 0x7e || c == '\\' || c == '"' {
+			return append(appendStringEscaped(append(b, s[:i]...), s[i:]), '"')
+		}
+	}
+	b = append(b, s...)
+	return append(b, '"')
+}
+
+func appendStringEscaped(b []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				b = append(b, s[start:i]...)
+				b = append(b, `\ufffd`...)
+				i++
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if c >= 0x20 && c != '\\' && c != '"' && c != 0x7f {
+			i++
+			continue
+		}
+		b = append(b, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			b = append(b, '\\', c)
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		}
+		i++
+		start = i
+	}
+	return append(b, s[start:]...)
+}
+
+// appendFloat appends f, with NaN and the infinities as strings since JSON
+// does not permit them.
+func appendFloat(b []byte, f float64, bitSize int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(b, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(b, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(b, f, 'f', -1, bitSize)
+}
+
+func appendTime(b []byte, t time.Time) []byte {
+	return append(t.AppendFormat(append(b, '"'), TimeFormat), '"')
+}
+
+// appendDuration appends d as an integer number of DurationUnit.
+func appendDuration(b []byte, d time.Duration) []byte {
+	return strconv.AppendInt(b, int64(d/DurationUnit), 10)
+}
+
+// appendInterface appends the JSON of v, or the marshaling error as a
+// string.
+func appendInterface(b []byte, v interface{}) []byte {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return appendString(b, "marshaling error: "+err.Error())
+	}
+	return append(b, j...)
+}
+
+// appendArray appends the JSON array of vals encoded by fn.
+func appendArray[T any](b []byte, vals []T, fn func([]byte, T) []byte) []byte {
+	b = append(b, '[')
+	for i, v := range vals {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = fn(b, v)
+	}
+	return append(b, ']')
+}
+
+func appendBool(b []byte, v bool) []byte { return strconv.AppendBool(b, v) }
+
+func appendInt[T int | int8 | int16 | int32 | int64](b []byte, v T) []byte {
+	return strconv.AppendInt(b, int64(v), 10)
+}
+
+func appendUint[T uint | uint8 | uint16 | uint32 | uint64](b []byte, v T) []byte {
+	return strconv.AppendUint(b, uint64(v), 10)
+}
+
+func appendFloat32(b []byte, v float32) []byte { return appendFloat(b, float64(v), 32) }
+func appendFloat64(b []byte, v float64) []byte { return appendFloat(b, v, 64) }
@@ -0,0 +1,340 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxPooledBuf is the capacity above which the buffer of an event is not
+// pooled, so that a huge event does not keep its memory.
+const maxPooledBuf = 64 << 10
+
+var eventPool = sync.Pool{
+	New: func() interface{} {
+		return &Event{buf: make([]byte, 0, 512)}
+	},
+}
+
+// Event is a log event started by one of the level methods of Logger and
+// sent by Msg or Msgf. The nil *Event of the disabled levels discards its
+// fields without encoding them.
+type Event struct {
+	buf  []byte
+	w    io.Writer
+	done func(msg string)
+}
+
+// Enabled reports whether the event is going to be written, e.g. to skip
+// the costly fields.
+func (e *Event) Enabled() bool {
+	return e != nil
+}
+
+// Msg sends the event with msg as the message field if not empty.
+// The event must not be used once sent.
+func (e *Event) Msg(msg string) {
+	if e == nil {
+		return
+	}
+	if msg != "" {
+		e.buf = appendString(appendKey(e.buf, MessageFieldName), msg)
+	}
+	e.buf = append(e.buf, '}', '\n')
+	if _, err := e.w.Write(e.buf); err != nil {
+		fmt.Fprintf(os.Stderr, "logger: could not write event: %v\n", err)
+	}
+	done := e.done
+	e.w, e.done = nil, nil
+	if cap(e.buf) <= maxPooledBuf {
+		eventPool.Put(e)
+	}
+	if done != nil {
+		done(msg)
+	}
+}
+
+// Msgf sends the event with the message of fmt.Sprintf(format, v...).
+func (e *Event) Msgf(format string, v ...interface{}) {
+	if e != nil {
+		e.Msg(fmt.Sprintf(format, v...))
+	}
+}
+
+// Err adds the error field with the message of err, if not nil.
+func (e *Event) Err(err error) *Event {
+	if e != nil && err != nil {
+		e.buf = appendString(appendKey(e.buf, ErrorFieldName), err.Error())
+	}
+	return e
+}
+
+// Str adds the field key with the string.
+func (e *Event) Str(key string, val string) *Event {
+	if e != nil {
+		e.buf = appendString(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Strs adds the field key with the strings.
+func (e *Event) Strs(key string, val []string) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendString)
+	}
+	return e
+}
+
+// Bool adds the field key with the bool.
+func (e *Event) Bool(key string, val bool) *Event {
+	if e != nil {
+		e.buf = appendBool(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Bools adds the field key with the bools.
+func (e *Event) Bools(key string, val []bool) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendBool)
+	}
+	return e
+}
+
+// Int adds the field key with the int.
+func (e *Event) Int(key string, val int) *Event {
+	if e != nil {
+		e.buf = appendInt(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Ints adds the field key with the ints.
+func (e *Event) Ints(key string, val []int) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendInt[int])
+	}
+	return e
+}
+
+// Int8 adds the field key with the int8.
+func (e *Event) Int8(key string, val int8) *Event {
+	if e != nil {
+		e.buf = appendInt(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Ints8 adds the field key with the int8s.
+func (e *Event) Ints8(key string, val []int8) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendInt[int8])
+	}
+	return e
+}
+
+// Int16 adds the field key with the int16.
+func (e *Event) Int16(key string, val int16) *Event {
+	if e != nil {
+		e.buf = appendInt(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Ints16 adds the field key with the int16s.
+func (e *Event) Ints16(key string, val []int16) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendInt[int16])
+	}
+	return e
+}
+
+// Int32 adds the field key with the int32.
+func (e *Event) Int32(key string, val int32) *Event {
+	if e != nil {
+		e.buf = appendInt(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Ints32 adds the field key with the int32s.
+func (e *Event) Ints32(key string, val []int32) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendInt[int32])
+	}
+	return e
+}
+
+// Int64 adds the field key with the int64.
+func (e *Event) Int64(key string, val int64) *Event {
+	if e != nil {
+		e.buf = appendInt(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Ints64 adds the field key with the int64s.
+func (e *Event) Ints64(key string, val []int64) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendInt[int64])
+	}
+	return e
+}
+
+// Uint adds the field key with the uint.
+func (e *Event) Uint(key string, val uint) *Event {
+	if e != nil {
+		e.buf = appendUint(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Uints adds the field key with the uints.
+func (e *Event) Uints(key string, val []uint) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendUint[uint])
+	}
+	return e
+}
+
+// Uint8 adds the field key with the uint8.
+func (e *Event) Uint8(key string, val uint8) *Event {
+	if e != nil {
+		e.buf = appendUint(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Uints8 adds the field key with the uint8s, as numbers.
+func (e *Event) Uints8(key string, val []uint8) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendUint[uint8])
+	}
+	return e
+}
+
+// Uint16 adds the field key with the uint16.
+func (e *Event) Uint16(key string, val uint16) *Event {
+	if e != nil {
+		e.buf = appendUint(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Uints16 adds the field key with the uint16s.
+func (e *Event) Uints16(key string, val []uint16) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendUint[uint16])
+	}
+	return e
+}
+
+// Uint32 adds the field key with the uint32.
+func (e *Event) Uint32(key string, val uint32) *Event {
+	if e != nil {
+		e.buf = appendUint(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Uints32 adds the field key with the uint32s.
+func (e *Event) Uints32(key string, val []uint32) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendUint[uint32])
+	}
+	return e
+}
+
+// Uint64 adds the field key with the uint64.
+func (e *Event) Uint64(key string, val uint64) *Event {
+	if e != nil {
+		e.buf = appendUint(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Uints64 adds the field key with the uint64s.
+func (e *Event) Uints64(key string, val []uint64) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendUint[uint64])
+	}
+	return e
+}
+
+// Float32 adds the field key with the float32.
+func (e *Event) Float32(key string, val float32) *Event {
+	if e != nil {
+		e.buf = appendFloat32(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Floats32 adds the field key with the float32s.
+func (e *Event) Floats32(key string, val []float32) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendFloat32)
+	}
+	return e
+}
+
+// Float64 adds the field key with the float64.
+func (e *Event) Float64(key string, val float64) *Event {
+	if e != nil {
+		e.buf = appendFloat64(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Floats64 adds the field key with the float64s.
+func (e *Event) Floats64(key string, val []float64) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendFloat64)
+	}
+	return e
+}
+
+// Time adds the field key with the time in TimeFormat.
+func (e *Event) Time(key string, val time.Time) *Event {
+	if e != nil {
+		e.buf = appendTime(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Times adds the field key with the times in TimeFormat.
+func (e *Event) Times(key string, val []time.Time) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendTime)
+	}
+	return e
+}
+
+// Dur adds the field key with the duration as an integer of DurationUnit.
+func (e *Event) Dur(key string, val time.Duration) *Event {
+	if e != nil {
+		e.buf = appendDuration(appendKey(e.buf, key), val)
+	}
+	return e
+}
+
+// Durs adds the field key with the durations as integers of DurationUnit.
+func (e *Event) Durs(key string, val []time.Duration) *Event {
+	if e != nil {
+		e.buf = appendArray(appendKey(e.buf, key), val, appendDuration)
+	}
+	return e
+}
+
+// Interface adds the field key with the JSON of val, e.g. a LogObj.
+func (e *Event) Interface(key string, val interface{}) *Event {
+	if e != nil {
+		e.buf = appendInterface(appendKey(e.buf, key), val)
+	}
+	return e
+}
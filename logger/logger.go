@@ -0,0 +1,206 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package logger is a leveled JSON logger that does not allocate on the
+// logging path. Each event is one line written by one Write call:
+//	{"t":"2017-06-01T10:00:00Z","l":"info","k":"v","rate":"15","m":"message"}
+// with the timestamp, the level, the fields of the sub-logger context, the
+// fields of the event and the message.
+// Usage example:
+/*
+	log := logger.New(os.Stderr, true).Level(logger.LogInfo)
+	log.Info().Str("rate", "15").Int("low", 16).Float32("high", 123.2).Msg("quote")
+
+	//sub-logger with the fields of the request
+	rlog := log.With().Str("request_id", id).Logger()
+	rlog.Error().Err(err).Msg("query failed")
+*/
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// The field names of the timestamp, the level, the message and the error.
+const (
+	TimestampFieldName = "t"
+	LevelFieldName     = "l"
+	MessageFieldName   = "m"
+	ErrorFieldName     = "e"
+)
+
+// TimeFormat is the format of the timestamps and of the Time fields.
+const TimeFormat = time.RFC3339
+
+// DurationUnit is the unit of the Dur fields, written as integers.
+const DurationUnit = time.Millisecond
+
+// LogObj or the composite of it that can be passed to the Interface
+// methods.
+type LogObj map[string]interface{}
+
+// Level is the level of the events.
+type Level uint8
+
+const (
+	LogDebug    Level = iota // LogDebug defines debug log level.
+	LogInfo                  // LogInfo defines info log level.
+	LogWarn                  // LogWarn defines warn log level.
+	LogError                 // LogError defines error log level.
+	LogFatal                 // LogFatal defines fatal log level.
+	LogPanic                 // LogPanic defines panic log level.
+	LogDisabled              // LogDisabled disables the logger.
+)
+
+var levelNames = [...]string{"debug", "info", "warn", "error", "fatal", "panic", "disabled"}
+
+// String returns the name of the level written in the events.
+func (l Level) String() string {
+	if int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return "unknown"
+}
+
+var globalLevel uint32
+
+// SetGlobalLevel sets the minimum level of all the loggers.
+func SetGlobalLevel(l Level) {
+	atomic.StoreUint32(&globalLevel, uint32(l))
+}
+
+// GlobalLevel returns the minimum level of all the loggers.
+func GlobalLevel() Level {
+	return Level(atomic.LoadUint32(&globalLevel))
+}
+
+// Logger writes the events to its writer. It is an immutable value, safe
+// for concurrent use; its methods return modified copies.
+// The zero Logger discards the events.
+type Logger struct {
+	w         io.Writer
+	level     Level
+	timestamp bool
+	context   []byte // the encoded fields of the context, without braces
+}
+
+// New creates a Logger writing to w, with the timestamp of the events if
+// timestamp. Each event is written by one call to w.Write, so w must be
+// safe for concurrent use when the Logger is used concurrently.
+func New(w io.Writer, timestamp bool) Logger {
+	if w == nil {
+		w = io.Discard
+	}
+	return Logger{w: w, timestamp: timestamp}
+}
+
+// Level returns a copy of the Logger with the minimum level lvl.
+func (l Logger) Level(lvl Level) Logger {
+	l.level = lvl
+	return l
+}
+
+// Timestamp returns a copy of the Logger that adds the timestamp to the
+// events if on.
+func (l Logger) Timestamp(on bool) Logger {
+	l.timestamp = on
+	return l
+}
+
+// With returns the Context of a sub-logger, to add the fields of all its
+// events.
+func (l Logger) With() Context {
+	return Context{l}
+}
+
+// Debug starts a new event with debug level.
+//
+// You must call Msg on the returned event in order to send the event.
+func (l Logger) Debug() *Event {
+	return l.newEvent(LogDebug, nil)
+}
+
+// Info starts a new event with info level.
+func (l Logger) Info() *Event {
+	return l.newEvent(LogInfo, nil)
+}
+
+// Warn starts a new event with warn level.
+func (l Logger) Warn() *Event {
+	return l.newEvent(LogWarn, nil)
+}
+
+// Error starts a new event with error level.
+func (l Logger) Error() *Event {
+	return l.newEvent(LogError, nil)
+}
+
+// Fatal starts a new event with fatal level. Msg calls os.Exit(1) once the
+// event is written.
+func (l Logger) Fatal() *Event {
+	return l.newEvent(LogFatal, func(msg string) { os.Exit(1) })
+}
+
+// Panic starts a new event with panic level. Msg panics with the message
+// once the event is written.
+func (l Logger) Panic() *Event {
+	return l.newEvent(LogPanic, func(msg string) { panic(msg) })
+}
+
+// WithLevel starts a new event with level lvl, without the Fatal and Panic
+// side effects.
+func (l Logger) WithLevel(lvl Level) *Event {
+	return l.newEvent(lvl, nil)
+}
+
+// Print sends a debug event with the message of fmt.Sprint(v...).
+func (l Logger) Print(v ...interface{}) {
+	if e := l.Debug(); e.Enabled() {
+		e.Msg(fmt.Sprint(v...))
+	}
+}
+
+// Printf sends a debug event with the message of fmt.Sprintf(format, v...).
+func (l Logger) Printf(format string, v ...interface{}) {
+	if e := l.Debug(); e.Enabled() {
+		e.Msg(fmt.Sprintf(format, v...))
+	}
+}
+
+// Write implements io.Writer, to be the output of the standard logger:
+// each line is sent as the message of an info event.
+func (l Logger) Write(p []byte) (int, error) {
+	n := len(p)
+	if n > 0 && p[n-1] == '\n' {
+		p = p[:n-1]
+	}
+	l.Info().Msg(string(p))
+	return n, nil
+}
+
+// Enabled reports whether the events of lvl are written.
+func (l Logger) Enabled(lvl Level) bool {
+	return l.w != nil && lvl < LogDisabled && lvl >= l.level && lvl >= GlobalLevel()
+}
+
+func (l Logger) newEvent(lvl Level, done func(string)) *Event {
+	if !l.Enabled(lvl) {
+		return nil
+	}
+	e := eventPool.Get().(*Event)
+	e.buf = append(e.buf[:0], '{')
+	e.w, e.done = l.w, done
+	if l.timestamp {
+		e.buf = appendTime(appendKey(e.buf, TimestampFieldName), time.Now())
+	}
+	e.buf = appendString(appendKey(e.buf, LevelFieldName), levelNames[lvl])
+	if len(l.context) > 0 {
+		e.buf = append(append(e.buf, ','), l.context...)
+	}
+	return e
+}
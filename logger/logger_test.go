@@ -0,0 +1,167 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logger_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/logger"
+)
+
+//Test cases for the event fields
+func TestEvent(t *testing.T) {
+	testCases := []struct {
+		log  func(l logger.Logger)
+		want string
+	}{
+		{func(l logger.Logger) { l.Info().Msg("hello") }, `{"l":"info","m":"hello"}`},
+		{func(l logger.Logger) { l.Warn().Msg("") }, `{"l":"warn"}`},
+		{func(l logger.Logger) {
+			l.Info().Str("rate", "15").Int("low", 16).Float32("high", 123.2).Msg("fox")
+		}, `{"l":"info","rate":"15","low":16,"high":123.2,"m":"fox"}`},
+		{func(l logger.Logger) {
+			l.Error().Err(errors.New("boom")).Err(nil).Dur("dur", 1500*time.Millisecond).Msg("failed")
+		}, `{"l":"error","e":"boom","dur":1500,"m":"failed"}`},
+		{func(l logger.Logger) {
+			l.Debug().Ints("i", []int{-1, 2}).Uints8("u", []uint8{1, 2}).Strs("s", nil).Bools("b", []bool{true}).Msg("arrays")
+		}, `{"l":"debug","i":[-1,2],"u":[1,2],"s":[],"b":[true],"m":"arrays"}`},
+		{func(l logger.Logger) {
+			l.Info().Float64("nan", math.NaN()).Float64("inf", math.Inf(-1)).Float64("f", 0.1).Msg("floats")
+		}, `{"l":"info","nan":"NaN","inf":"-Inf","f":0.1,"m":"floats"}`},
+		{func(l logger.Logger) {
+			l.Info().Str("q", "a\"b\\c\nd\te\x01\x7f").Str("u", "héllo\xff").Msg("esc")
+		}, `{"l":"info","q":"a\"b\\c\nd\te\u0001\u007f","u":"héllo\ufffd","m":"esc"}`},
+		{func(l logger.Logger) {
+			l.Info().Time("t0", time.Time{}).Interface("obj", logger.LogObj{"b": 1, "a": []int{2}}).
+				Interface("bad", make(chan int)).Msg("")
+		}, `{"l":"info","t0":"0001-01-01T00:00:00Z","obj":{"a":[2],"b":1},"bad":"marshaling error: json: unsupported type: chan int"}`},
+		{func(l logger.Logger) { l.Info().Msgf("%d-%s", 7, "x") }, `{"l":"info","m":"7-x"}`},
+		{func(l logger.Logger) { l.Print("a", 1) }, `{"l":"debug","m":"a1"}`},
+		{func(l logger.Logger) { l.WithLevel(logger.LogWarn).Msg("w") }, `{"l":"warn","m":"w"}`},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		tc.log(logger.New(out, false))
+		if got := out.String(); got != tc.want+"\n" {
+			t.Errorf("Test case %d failed\ngot:  %s\nwant: %s", i, got, tc.want)
+		}
+	}
+}
+
+func TestSublogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	parent := logger.New(out, false).With().Str("k0", "v0").Logger()
+	a := parent.With().Str("a", "1")
+	b := parent.With().Str("b", "2")
+	//a shared parent context must not be overwritten by its siblings
+	a1, a2 := a.Str("x", "1").Logger(), a.Str("y", "2").Logger()
+
+	testCases := []struct {
+		l    logger.Logger
+		want string
+	}{
+		{parent, `{"l":"info","k0":"v0","m":"s"}`},
+		{a.Logger(), `{"l":"info","k0":"v0","a":"1","m":"s"}`},
+		{b.Logger(), `{"l":"info","k0":"v0","b":"2","m":"s"}`},
+		{a1, `{"l":"info","k0":"v0","a":"1","x":"1","m":"s"}`},
+		{a2, `{"l":"info","k0":"v0","a":"1","y":"2","m":"s"}`},
+		{a2.With().Err(errors.New("e1")).Int("n", 3).Logger(), `{"l":"info","k0":"v0","a":"1","y":"2","e":"e1","n":3,"m":"s"}`},
+	}
+	for i, tc := range testCases {
+		out.Reset()
+		tc.l.Info().Msg("s")
+		if got := out.String(); got != tc.want+"\n" {
+			t.Errorf("Test case %d failed\ngot:  %s\nwant: %s", i, got, tc.want)
+		}
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	re := regexp.MustCompile(`^\{"t":"\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d[^"]*","l":"info","k":"v","m":"s"\}` + "\n$")
+	testCases := []struct {
+		l    func(w io.Writer) logger.Logger
+		want bool
+	}{
+		{func(w io.Writer) logger.Logger { return logger.New(w, true) }, true},
+		{func(w io.Writer) logger.Logger { return logger.New(w, false).Timestamp(true) }, true},
+		{func(w io.Writer) logger.Logger { return logger.New(w, false).With().Timestamp().Logger() }, true},
+		{func(w io.Writer) logger.Logger { return logger.New(w, true).Timestamp(false) }, false},
+	}
+	for i, tc := range testCases {
+		out := &bytes.Buffer{}
+		tc.l(out).With().Str("k", "v").Logger().Info().Msg("s")
+		if re.MatchString(out.String()) != tc.want {
+			t.Errorf("Test case %d failed, got: %s", i, out.String())
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := logger.New(out, false).Level(logger.LogWarn)
+	l.Info().Str("k", "v").Msg("s")
+	l.Debug().Msg("s")
+	l.Print("s")
+	if out.Len() != 0 || l.Info().Enabled() || !l.Error().Enabled() {
+		t.Errorf("Level failed, got: %s", out.String())
+	}
+	l.Error().Msg("s")
+	if out.String() != `{"l":"error","m":"s"}`+"\n" {
+		t.Errorf("Level failed, got: %s", out.String())
+	}
+
+	logger.SetGlobalLevel(logger.LogDisabled)
+	if l.Panic().Enabled() || logger.GlobalLevel() != logger.LogDisabled {
+		t.Errorf("SetGlobalLevel failed")
+	}
+	logger.SetGlobalLevel(logger.LogDebug)
+
+	var zero logger.Logger
+	zero.Error().Str("k", "v").Msg("discarded")
+	if zero.Error().Enabled() || logger.LogWarn.String() != "warn" || logger.Level(42).String() != "unknown" {
+		t.Errorf("Zero Logger failed")
+	}
+}
+
+func TestPanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	defer func() {
+		if v := recover(); v != "oops" || out.String() != `{"l":"panic","m":"oops"}`+"\n" {
+			t.Errorf("Panic failed, got: %v %s", v, out.String())
+		}
+	}()
+	logger.New(out, false).Panic().Msg("oops")
+}
+
+func TestWrite(t *testing.T) {
+	out := &bytes.Buffer{}
+	n, err := logger.New(out, false).With().Str("k", "v").Logger().Write([]byte("std log line\n"))
+	if n != 13 || err != nil || out.String() != `{"l":"info","k":"v","m":"std log line"}`+"\n" {
+		t.Errorf("Write failed, got: %d %v %s", n, err, out.String())
+	}
+}
+
+func TestZeroAlloc(t *testing.T) {
+	l := logger.New(io.Discard, true).With().Str("k", "v").Logger()
+	err := errors.New("boom")
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Info().Str("rate", "15").Int("low", 16).Float32("high", 123.2).Err(err).
+			Dur("dur", time.Second).Ints("ints", []int{1, 2}).Msg("The quick brown fox")
+		l.Debug().Str("k", "v").Msg("")
+	})
+	if allocs != 0 {
+		t.Errorf("Logging allocated %v times", allocs)
+	}
+	disabled := l.Level(logger.LogError)
+	if allocs := testing.AllocsPerRun(100, func() { disabled.Info().Str("k", "v").Msg("s") }); allocs != 0 {
+		t.Errorf("Disabled logging allocated %v times", allocs)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package server runs the http.Handler of a golight application with a
+// graceful lifecycle: it serves until SIGINT or SIGTERM, then drains the
+// in-flight requests and runs the shutdown hooks.
+// Usage example:
+/*
+	func main() {
+		mux := ghttp.NewMux()
+		mux.Handle("/test", ghttp.Router{"GET": getHandler, "POST": postHandler})
+
+		srv := server.New(server.Config{Addr: ":8080", Handler: mux})
+		srv.OnShutdown("db", func(ctx context.Context) error { return db.Close() })
+		if err := srv.ListenAndServe(); err != nil {
+			os.Exit(1)
+		}
+	}
+*/
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/logger"
+)
+
+// Config configures the Server.
+type Config struct {
+	// Addr is the TCP address host:port, or unix:/path/of.sock for a
+	// Unix socket.
+	Addr    string
+	Handler http.Handler
+
+	// ShutdownDelay is how long the server keeps serving once the shutdown
+	// started, with Draining reporting true, so that the load balancers
+	// see the readiness fail and stop sending requests.
+	ShutdownDelay time.Duration
+	// DrainPeriod is how long the in-flight requests are waited for before
+	// the connections are closed, 30s if 0.
+	DrainPeriod time.Duration
+	// HookTimeout limits each shutdown hook, 10s if 0.
+	HookTimeout time.Duration
+	// Signals start the shutdown, SIGINT and SIGTERM if nil.
+	Signals []os.Signal
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// Logger reports the startup and the shutdown, to os.Stderr with
+	// timestamps if not set.
+	Logger *logger.Context
+}
+
+// Server is an http.Server with a graceful lifecycle.
+type Server struct {
+	cfg  Config
+	srv  *http.Server
+	log  logger.Logger
+	stop chan struct{}
+	once sync.Once
+
+	inFlight int64
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	hooks []hook
+	addr  net.Addr
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New creates a Server.
+func New(cfg Config) *Server {
+	if cfg.DrainPeriod <= 0 {
+		cfg.DrainPeriod = 30 * time.Second
+	}
+	if cfg.HookTimeout <= 0 {
+		cfg.HookTimeout = 10 * time.Second
+	}
+	if cfg.Signals == nil {
+		cfg.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	lc := logging.NewContextWithTimestamp(os.Stderr)
+	if cfg.Logger != nil {
+		lc = *cfg.Logger
+	}
+	s := &Server{cfg: cfg, log: lc.Str("component", "server").Logger(), stop: make(chan struct{})}
+	s.srv = &http.Server{
+		Handler:           http.HandlerFunc(s.serveHTTP),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&s.inFlight, 1)
+	defer atomic.AddInt64(&s.inFlight, -1)
+	s.cfg.Handler.ServeHTTP(w, r)
+}
+
+// InFlight returns the number of requests being served.
+func (s *Server) InFlight() int64 {
+	return atomic.LoadInt64(&s.inFlight)
+}
+
+// Draining reports whether the shutdown started, e.g. for the readiness
+// checks to fail.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// Addr returns the address the server listens on, nil before it listens.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// OnShutdown registers a hook run once the requests are drained, e.g. to
+// close the database. The hooks run in the reverse order of their
+// registration, like defers, and their errors are logged.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook{name, fn})
+}
+
+// Shutdown starts the shutdown as a signal does. ListenAndServe and Serve
+// return once it is complete.
+func (s *Server) Shutdown() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+// Listen listens on the address of the config, tcp by default or unix
+// with the unix: prefix. The stale socket file of a Unix socket is
+// removed first.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ListenAndServe listens on the Addr of the config and serves until the
+// shutdown is complete. It returns nil after a graceful shutdown, or
+// context.DeadlineExceeded when the DrainPeriod was exceeded.
+func (s *Server) ListenAndServe() error {
+	l, err := Listen(s.cfg.Addr)
+	if err != nil {
+		s.log.Error().Err(err).Str("addr", s.cfg.Addr).Msg("listen failed")
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves on the listener until the shutdown is complete, refer to
+// ListenAndServe.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.addr = l.Addr()
+	s.mu.Unlock()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, s.cfg.Signals...)
+	defer signal.Stop(sigs)
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.srv.Serve(l) }()
+	s.log.Info().Str("addr", addrString(l.Addr())).Int("pid", os.Getpid()).Msg("server started")
+
+	start := time.Now()
+	select {
+	case err := <-serveErr:
+		s.log.Error().Err(err).Msg("server failed")
+		s.runHooks()
+		return err
+	case sig := <-sigs:
+		s.log.Info().Str("signal", sig.String()).Int64("in_flight", s.InFlight()).Msg("shutdown started")
+	case <-s.stop:
+		s.log.Info().Int64("in_flight", s.InFlight()).Msg("shutdown started")
+	}
+	s.draining.Store(true)
+	if s.cfg.ShutdownDelay > 0 {
+		time.Sleep(s.cfg.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainPeriod)
+	err := s.srv.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		s.log.Warn().Err(err).Int64("in_flight", s.InFlight()).Msg("drain period exceeded, closing the connections")
+		s.srv.Close()
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.log.Error().Err(err).Msg("server failed")
+	}
+	s.runHooks()
+	s.log.Info().Dur("dur", time.Since(start)).Msg("server stopped")
+	return err
+}
+
+func (s *Server) runHooks() {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hk := hooks[i]
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.HookTimeout)
+		err := runHook(ctx, hk)
+		cancel()
+		if err != nil {
+			s.log.Error().Err(err).Str("hook", hk.name).Msg("shutdown hook failed")
+		}
+	}
+}
+
+// runHook runs the hook, recovering from its panic so the next hooks run.
+func runHook(ctx context.Context, hk hook) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return hk.fn(ctx)
+}
+
+func addrString(a net.Addr) string {
+	if a.Network() == "unix" {
+		return "unix:" + a.String()
+	}
+	return a.String()
+}
@@ -0,0 +1,157 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dlmc/golight/decorator/logging"
+	"github.com/dlmc/golight/server"
+)
+
+// syncBuffer is a bytes.Buffer safe for the concurrent log writes
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.String()
+}
+
+//Test cases for the graceful shutdown
+func TestGracefulShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var srv *server.Server
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+	out := &syncBuffer{}
+	lc := logging.NewContext(out)
+	srv = server.New(server.Config{Handler: handler, Logger: &lc, Signals: []os.Signal{syscall.SIGUSR1}})
+	var order []string
+	srv.OnShutdown("first", func(ctx context.Context) error { order = append(order, "first"); return nil })
+	srv.OnShutdown("second", func(ctx context.Context) error { order = append(order, "second"); return errors.New("oops") })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body <- string(b)
+	}()
+	<-started
+	if srv.InFlight() != 1 || srv.Draining() {
+		t.Errorf("InFlight failed, got: %d %v", srv.InFlight(), srv.Draining())
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	for !srv.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("Graceful shutdown dropped the request, got: %s", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve failed, got: %v", err)
+	}
+	if strings.Join(order, ",") != "second,first" || srv.InFlight() != 0 {
+		t.Errorf("Shutdown hooks failed, got: %v", order)
+	}
+
+	logs := out.String()
+	for _, want := range []string{`"m":"server started"`, `"signal":"user defined signal 1"`, `"in_flight":1`,
+		`"hook":"second"`, `"e":"oops"`, `"m":"server stopped"`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Server logs missing %s, got: %s", want, logs)
+		}
+	}
+}
+
+func TestDrainPeriod(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	lc := logging.NewContext(io.Discard)
+	srv := server.New(server.Config{Handler: handler, Logger: &lc, DrainPeriod: 20 * time.Millisecond})
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+	go http.Get("http://" + l.Addr().String())
+	<-started
+
+	srv.Shutdown()
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain period failed, got: %v", err)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golight.sock")
+	lc := logging.NewContext(io.Discard)
+	srv := server.New(server.Config{Addr: "unix:" + path, Logger: &lc,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("unix")) })})
+	done := make(chan error, 1)
+	go func() { done <- srv.ListenAndServe() }()
+	for srv.Addr() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "unix" {
+		t.Errorf("Unix socket failed, got: %s", b)
+	}
+	srv.Shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("Unix socket shutdown failed, got: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Unix socket file not removed, got: %v", err)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package validate validates structs with the rules declared in their
+// `validate` struct tags.
+//
+// The rules are separated by commas:
+//	required        the value must not be empty, nil or zero
+//	min=n           numbers must be >= n, strings, slices and maps must
+//	                have at least n characters / items
+//	max=n           numbers must be <= n, strings, slices and maps must
+//	                have at most n characters / items
+//	len=n           strings, slices and maps must have exactly n characters / items
+//	oneof=a b c     the value must be one of the space separated values
+//	email           the string must be an email address
+//	regexp=re       the string must match re, it takes the rest of the tag
+//	                so re may contain commas
+// Nil pointers are only checked by required, the other rules apply to
+// the value pointed to. Nested structs, pointers to structs and the
+// elements of slices, arrays and maps of structs are validated as well.
+// Usage example:
+/*
+	type item struct {
+		SKU   string `json:"sku" validate:"required,regexp=^[A-Z]{2}[0-9]{4}$"`
+		Count int    `json:"count" validate:"min=1,max=99"`
+	}
+	type order struct {
+		Email string `json:"email" validate:"required,email"`
+		Items []item `json:"items" validate:"required,max=10"`
+	}
+
+	if err := validate.Struct(&o); err != nil {
+		h.Err = err //respond.CreateDecor responds with 422 and the field errors
+		return c
+	}
+*/
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError reports a rule a field failed.
+type FieldError struct {
+	Field   string `json:"field"` // path of the field, e.g. items[2].sku
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (fe FieldError) Error() string {
+	return fe.Field + " " + fe.Message
+}
+
+// Errors is the list of the field errors returned by Struct.
+// It is reported by respond.CreateDecor as a 422 Response with the list
+// of FieldError in Data.
+type Errors []FieldError
+
+func (es Errors) Error() string {
+	strs := make([]string, len(es))
+	for i, fe := range es {
+		strs[i] = fe.Error()
+	}
+	return "validate: " + strings.Join(strs, "; ")
+}
+
+// StatusCode returns 422 Unprocessable Entity.
+func (es Errors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// ResponseData returns the field errors.
+func (es Errors) ResponseData() interface{} {
+	return []FieldError(es)
+}
+
+// Struct validates the struct, or pointer to struct, v.
+// It returns Errors when a rule failed, or an error when the rules
+// declared in the tags are invalid.
+func Struct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: Struct requires a struct, got %T", v)
+	}
+	var es Errors
+	if err := validateStruct(rv, "", &es); err != nil {
+		return err
+	}
+	if len(es) > 0 {
+		return es
+	}
+	return nil
+}
+
+type rule struct {
+	name  string
+	param string
+	num   float64
+	rex   *regexp.Regexp
+	oneof []string
+}
+
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// The parsed fields are cached per struct type
+var cache sync.Map // map[reflect.Type][]field
+
+func structFields(rt reflect.Type) ([]field, error) {
+	if fs, ok := cache.Load(rt); ok {
+		return fs.([]field), nil
+	}
+	var fs []field
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "-" || (!sf.IsExported() && !sf.Anonymous) {
+			continue
+		}
+		rules, err := parseRules(tag)
+		if err != nil {
+			return nil, fmt.Errorf("validate: %s.%s: %v", rt, sf.Name, err)
+		}
+		name := sf.Name
+		if jn := strings.Split(sf.Tag.Get("json"), ",")[0]; jn != "" && jn != "-" {
+			name = jn
+		}
+		if sf.Anonymous {
+			name = ""
+		}
+		fs = append(fs, field{index: i, name: name, rules: rules})
+	}
+	cache.Store(rt, fs)
+	return fs, nil
+}
+
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for tag != "" {
+		var part string
+		if strings.HasPrefix(tag, "regexp=") {
+			part, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			part, tag = tag, ""
+		}
+		if part == "" {
+			continue
+		}
+		r := rule{name: part}
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			r.name, r.param = part[:i], part[i+1:]
+		}
+		var err error
+		switch r.name {
+		case "required", "email":
+		case "min", "max", "len":
+			r.num, err = strconv.ParseFloat(r.param, 64)
+		case "oneof":
+			r.oneof = strings.Fields(r.param)
+		case "regexp":
+			r.rex, err = regexp.Compile(r.param)
+		default:
+			err = fmt.Errorf("unknown rule %q", r.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func validateStruct(rv reflect.Value, path string, es *Errors) error {
+	fs, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fs {
+		fpath := path
+		if f.name != "" {
+			if fpath != "" {
+				fpath += "."
+			}
+			fpath += f.name
+		}
+		if err := validateField(rv.Field(f.index), fpath, f.rules, es); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateField(fv reflect.Value, path string, rules []rule, es *Errors) error {
+	for _, r := range rules {
+		if r.name == "required" && isEmpty(fv) {
+			*es = append(*es, FieldError{Field: path, Rule: r.name, Message: "is required"})
+			return nil
+		}
+	}
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	for _, r := range rules {
+		if msg := check(fv, r); msg != "" {
+			*es = append(*es, FieldError{Field: path, Rule: r.name, Param: r.param, Message: msg})
+		}
+	}
+	return dive(fv, path, es)
+}
+
+// dive validates the nested structs of the value.
+func dive(fv reflect.Value, path string, es *Errors) error {
+	switch fv.Kind() {
+	case reflect.Struct:
+		return validateStruct(fv, path, es)
+	case reflect.Slice, reflect.Array:
+		if !hasStruct(fv.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < fv.Len(); i++ {
+			if err := validateField(fv.Index(i), fmt.Sprintf("%s[%d]", path, i), nil, es); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !hasStruct(fv.Type().Elem()) {
+			return nil
+		}
+		iter := fv.MapRange()
+		for iter.Next() {
+			if err := validateField(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), nil, es); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasStruct(rt reflect.Type) bool {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.Kind() == reflect.Struct
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return fv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	}
+	return fv.IsZero()
+}
+
+// check returns the error message of the failed rule, "" otherwise.
+func check(fv reflect.Value, r rule) string {
+	switch r.name {
+	case "min", "max", "len":
+		n, isLen, ok := size(fv)
+		if !ok {
+			return "has an unsupported type for " + r.name
+		}
+		unit := ""
+		if isLen {
+			unit = " items"
+			if fv.Kind() == reflect.String {
+				unit = " characters"
+			}
+		}
+		switch {
+		case r.name == "min" && n < r.num:
+			if isLen {
+				return "must have at least " + r.param + unit
+			}
+			return "must be at least " + r.param
+		case r.name == "max" && n > r.num:
+			if isLen {
+				return "must have at most " + r.param + unit
+			}
+			return "must be at most " + r.param
+		case r.name == "len" && (!isLen || n != r.num):
+			return "must have exactly " + r.param + unit
+		}
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, o := range r.oneof {
+			if s == o {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(r.oneof, ", ")
+	case "email":
+		if fv.Kind() != reflect.String {
+			return "has an unsupported type for email"
+		}
+		if a, err := mail.ParseAddress(fv.String()); err != nil || a.Address != fv.String() {
+			return "must be a valid email address"
+		}
+	case "regexp":
+		if fv.Kind() != reflect.String {
+			return "has an unsupported type for regexp"
+		}
+		if !r.rex.MatchString(fv.String()) {
+			return "must match " + r.param
+		}
+	}
+	return ""
+}
+
+// size returns the number or length the min, max and len rules compare.
+func size(fv reflect.Value) (n float64, isLen, ok bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(fv.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(fv.Len()), true, true
+	}
+	return 0, false, false
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 The Golight Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package validate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dlmc/golight/validate"
+)
+
+type item struct {
+	SKU   string `json:"sku" validate:"required,regexp=^[A-Z]{2}[0-9]{1,4}$"`
+	Count int    `json:"count" validate:"min=1,max=99"`
+}
+
+type address struct {
+	City string `validate:"required,max=5"`
+}
+
+type order struct {
+	Email    string          `json:"email" validate:"required,email"`
+	Name     string          `json:"name,omitempty" validate:"min=2,max=5"`
+	Code     string          `json:"code" validate:"len=3"`
+	Status   string          `json:"status" validate:"oneof=new paid"`
+	Priority int             `json:"priority" validate:"oneof=1 2 3"`
+	Note     *string         `json:"note" validate:"min=2"`
+	Items    []item          `json:"items" validate:"required,max=3"`
+	Ship     *address        `json:"ship"`
+	Tags     map[string]item `json:"tags"`
+	Ignored  string          `validate:"-"`
+}
+
+func validOrder() order {
+	return order{Email: "a@b.co", Name: "abc", Code: "x12", Status: "new", Priority: 1,
+		Items: []item{{"AB1", 1}, {"CD12", 99}}}
+}
+
+//Test case for a valid struct
+func TestStructValid(t *testing.T) {
+	o := validOrder()
+	if err := validate.Struct(&o); err != nil {
+		t.Errorf("Struct failed, got: %v, want: nil", err)
+	}
+	if err := validate.Struct(o); err != nil {
+		t.Errorf("Struct failed, got: %v, want: nil", err)
+	}
+}
+
+//Test cases for the field errors
+func TestStructErrors(t *testing.T) {
+	note := "x"
+	o := validOrder()
+	o.Email = "not an email"
+	o.Name = "abcdef"
+	o.Code = "x1"
+	o.Status = "lost"
+	o.Priority = 4
+	o.Note = &note
+	o.Items = append(o.Items, item{"", 0}, item{"ab1", 100})
+	o.Ship = &address{City: "Springfield"}
+	o.Tags = map[string]item{"k": {"AB1", 0}}
+
+	want := validate.Errors{
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "name", Rule: "max", Param: "5", Message: "must have at most 5 characters"},
+		{Field: "code", Rule: "len", Param: "3", Message: "must have exactly 3 characters"},
+		{Field: "status", Rule: "oneof", Param: "new paid", Message: "must be one of new, paid"},
+		{Field: "priority", Rule: "oneof", Param: "1 2 3", Message: "must be one of 1, 2, 3"},
+		{Field: "note", Rule: "min", Param: "2", Message: "must have at least 2 characters"},
+		{Field: "items", Rule: "max", Param: "3", Message: "must have at most 3 items"},
+		{Field: "items[2].sku", Rule: "required", Message: "is required"},
+		{Field: "items[2].count", Rule: "min", Param: "1", Message: "must be at least 1"},
+		{Field: "items[3].sku", Rule: "regexp", Param: "^[A-Z]{2}[0-9]{1,4}$", Message: "must match ^[A-Z]{2}[0-9]{1,4}$"},
+		{Field: "items[3].count", Rule: "max", Param: "99", Message: "must be at most 99"},
+		{Field: "ship.City", Rule: "max", Param: "5", Message: "must have at most 5 characters"},
+		{Field: "tags[k].count", Rule: "min", Param: "1", Message: "must be at least 1"},
+	}
+
+	err := validate.Struct(&o)
+	var es validate.Errors
+	if !errors.As(err, &es) {
+		t.Fatalf("Struct failed, got: %v, want: validate.Errors", err)
+	}
+	if !reflect.DeepEqual(es, want) {
+		t.Errorf("Struct failed, got:\n%+v\nwant:\n%+v", es, want)
+	}
+	if es.StatusCode() != 422 {
+		t.Errorf("StatusCode failed, got: %d, want: 422", es.StatusCode())
+	}
+
+	o = order{}
+	err = validate.Struct(&o)
+	if got := err.Error(); got != "validate: email is required; name must have at least 2 characters; code must have exactly 3 characters; "+
+		"status must be one of new, paid; priority must be one of 1, 2, 3; items is required" {
+		t.Errorf("Error failed, got: %s", got)
+	}
+}
+
+//Test cases for the invalid rules and values
+func TestStructInvalid(t *testing.T) {
+	var bad struct {
+		A string `validate:"unknown"`
+	}
+	if err := validate.Struct(&bad); err == nil {
+		t.Errorf("Struct with unknown rule did not fail")
+	}
+	if err := validate.Struct(1); err == nil {
+		t.Errorf("Struct with int did not fail")
+	}
+}